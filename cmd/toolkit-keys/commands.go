@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/MicahParks/jwkset"
+	"github.com/hiendaovinh/toolkit/pkg/cert"
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+)
+
+func pbes2Options(kdf string) (cert.PBES2Options, error) {
+	opts := cert.DefaultPBES2Options
+	switch kdf {
+	case "pbkdf2":
+		opts.KDF = cert.KDFPBKDF2
+	case "scrypt":
+		opts.KDF = cert.KDFScrypt
+	default:
+		return opts, fmt.Errorf("invalid kdf %q", kdf)
+	}
+
+	return opts, nil
+}
+
+func writeSigner(path string, signer crypto.Signer, passSource string, kdf string) error {
+	password, err := readPassword(passSource)
+	if err != nil {
+		return err
+	}
+
+	opts, err := pbes2Options(kdf)
+	if err != nil {
+		return err
+	}
+
+	pemPUB, pemPRIV, err := cert.MarshalPKCS8WithOptions(signer, password, opts)
+	if err != nil {
+		return err
+	}
+
+	return cert.WritePair(path, pemPUB, pemPRIV)
+}
+
+func loadSigner(path string, passSource string) (crypto.Signer, error) {
+	password, err := readPassword(passSource)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return cert.LoadPair(b, password)
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func runGenerate(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	keyType := fs.String("type", string(cert.KeyTypeED25519), "key type: ed25519, rsa-2048, rsa-3072, rsa-4096, ecdsa-p256 or ecdsa-p384")
+	out := fs.String("out", "", "output file")
+	pass := fs.String("pass", "", "password source")
+	kdf := fs.String("kdf", "pbkdf2", "key derivation: pbkdf2 or scrypt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required(map[string]string{"out": *out, "pass": *pass}); err != nil {
+		return err
+	}
+
+	signer, err := cert.GenerateKey(cert.KeyType(*keyType))
+	if err != nil {
+		return err
+	}
+
+	return writeSigner(*out, signer, *pass, *kdf)
+}
+
+func runReencrypt(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("reencrypt", flag.ContinueOnError)
+	in := fs.String("in", "", "input file, - for stdin")
+	pass := fs.String("pass", "", "current password source")
+	out := fs.String("out", "", "output file")
+	newPass := fs.String("newpass", "", "new password source")
+	kdf := fs.String("kdf", "pbkdf2", "key derivation: pbkdf2 or scrypt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required(map[string]string{"out": *out, "pass": *pass, "newpass": *newPass}); err != nil {
+		return err
+	}
+
+	signer, err := loadSigner(*in, *pass)
+	if err != nil {
+		return err
+	}
+
+	return writeSigner(*out, signer, *newPass, *kdf)
+}
+
+func runJWK(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("jwk", flag.ContinueOnError)
+	in := fs.String("in", "", "input file, - for stdin")
+	pass := fs.String("pass", "", "password source")
+	set := fs.Bool("set", false, "print a JWKS instead of a single JWK")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required(map[string]string{"pass": *pass}); err != nil {
+		return err
+	}
+
+	signer, err := loadSigner(*in, *pass)
+	if err != nil {
+		return err
+	}
+
+	a, err := jwtx.NewAuthorityFromSigner("", time.Minute, signer)
+	if err != nil {
+		return err
+	}
+
+	if *set {
+		b, err := a.PublicJWKS(context.Background())
+		if err != nil {
+			return err
+		}
+
+		return printJSON(stdout, json.RawMessage(b))
+	}
+
+	jwk, err := a.PublicJWK(context.Background())
+	if err != nil {
+		return err
+	}
+
+	return printJSON(stdout, jwk.Marshal())
+}
+
+func runPEM2JWK(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("pem2jwk", flag.ContinueOnError)
+	in := fs.String("in", "", "input file, - for stdin")
+	pass := fs.String("pass", "", "password source")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required(map[string]string{"pass": *pass}); err != nil {
+		return err
+	}
+
+	signer, err := loadSigner(*in, *pass)
+	if err != nil {
+		return err
+	}
+
+	if priv, ok := signer.(ed25519.PrivateKey); ok {
+		jwk, err := cert.ED25519PrivateJWK(priv)
+		if err != nil {
+			return err
+		}
+
+		return printJSON(stdout, jwk)
+	}
+
+	// the public JWK of an Authority carries the kid and alg verifiers expect
+	a, err := jwtx.NewAuthorityFromSigner("", time.Minute, signer)
+	if err != nil {
+		return err
+	}
+
+	pub, err := a.PublicJWK(context.Background())
+	if err != nil {
+		return err
+	}
+
+	jwk, err := jwkset.NewJWKFromKey(signer, jwkset.JWKOptions{
+		Marshal: jwkset.JWKMarshalOptions{Private: true},
+		Metadata: jwkset.JWKMetadataOptions{
+			ALG: pub.Marshal().ALG,
+			KID: pub.Marshal().KID,
+			USE: jwkset.UseSig,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return printJSON(stdout, jwk.Marshal())
+}
+
+func runJWK2PEM(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("jwk2pem", flag.ContinueOnError)
+	in := fs.String("in", "", "private JWK file, - for stdin")
+	out := fs.String("out", "", "output file")
+	newPass := fs.String("newpass", "", "password source")
+	kdf := fs.String("kdf", "pbkdf2", "key derivation: pbkdf2 or scrypt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required(map[string]string{"out": *out, "newpass": *newPass}); err != nil {
+		return err
+	}
+
+	b, err := readInput(*in)
+	if err != nil {
+		return err
+	}
+
+	var okp cert.JWK
+	if err := json.Unmarshal(b, &okp); err != nil {
+		return err
+	}
+
+	if okp.Kty == "OKP" {
+		_, priv, err := cert.ED25519FromJWK(okp)
+		if err != nil {
+			return err
+		}
+
+		return writeSigner(*out, priv, *newPass, *kdf)
+	}
+
+	jwk, err := jwkset.NewJWKFromRawJSON(b, jwkset.JWKMarshalOptions{Private: true}, jwkset.JWKValidateOptions{})
+	if err != nil {
+		return err
+	}
+
+	signer, ok := jwk.Key().(crypto.Signer)
+	if !ok {
+		return cert.ErrInvalidKeyPair
+	}
+
+	return writeSigner(*out, signer, *newPass, *kdf)
+}
+
+func runToken(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("token", flag.ContinueOnError)
+	in := fs.String("in", "", "input file, - for stdin")
+	pass := fs.String("pass", "", "password source")
+	issuer := fs.String("issuer", "toolkit-keys", "iss claim")
+	subject := fs.String("sub", "", "sub claim")
+	audience := fs.String("aud", "", "comma separated aud claim")
+	metadata := fs.String("meta", "", "metadata claim as a JSON object")
+	expiration := fs.Duration("exp", time.Hour, "token lifetime")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := required(map[string]string{"pass": *pass, "sub": *subject}); err != nil {
+		return err
+	}
+
+	signer, err := loadSigner(*in, *pass)
+	if err != nil {
+		return err
+	}
+
+	var meta map[string]any
+	if *metadata != "" {
+		if err := json.Unmarshal([]byte(*metadata), &meta); err != nil {
+			return fmt.Errorf("invalid -meta: %w", err)
+		}
+	}
+
+	var aud []string
+	if *audience != "" {
+		aud = strings.Split(*audience, ",")
+	}
+
+	a, err := jwtx.NewAuthorityFromSigner(*issuer, *expiration, signer)
+	if err != nil {
+		return err
+	}
+
+	_, token, err := a.IssueToken(context.Background(), *subject, aud, meta)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(stdout, token)
+	return err
+}
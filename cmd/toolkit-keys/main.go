@@ -0,0 +1,123 @@
+// toolkit-keys manages the key pairs used by cert and jwtx.
+//
+// Passwords are given as pass:<password>, env:<variable> or file:<path>, the same way openssl does.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{"generate", "generate an encrypted key pair", runGenerate},
+	{"reencrypt", "re-encrypt a key pair with a new password", runReencrypt},
+	{"jwk", "print the public JWK or JWKS of a key pair", runJWK},
+	{"pem2jwk", "convert a key pair to a JWK, including the private key", runPEM2JWK},
+	{"jwk2pem", "convert a private JWK to an encrypted key pair", runJWK2PEM},
+	{"token", "mint a token for local debugging", runToken},
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: toolkit-keys <command> [flags]")
+	fmt.Fprintln(w)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "run toolkit-keys <command> -h for the flags of a command")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+
+		err := c.run(os.Args[2:], os.Stdout)
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "toolkit-keys %s: %s\n", name, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	usage(os.Stderr)
+	os.Exit(2)
+}
+
+// readPassword resolves pass:, env: and file: sources.
+func readPassword(source string) ([]byte, error) {
+	kind, value, ok := strings.Cut(source, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid password source %q", source)
+	}
+
+	switch kind {
+	case "pass":
+		return []byte(value), nil
+	case "env":
+		v, ok := os.LookupEnv(value)
+		if !ok {
+			return nil, fmt.Errorf("missing env: %s", value)
+		}
+
+		return []byte(v), nil
+	case "file":
+		b, err := os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+
+		line, _, _ := strings.Cut(string(b), "\n")
+		return []byte(strings.TrimRight(line, "\r")), nil
+	}
+
+	return nil, fmt.Errorf("invalid password source %q", source)
+}
+
+func readInput(path string) ([]byte, error) {
+	if path == "" || path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+
+	return os.ReadFile(path)
+}
+
+// required reports every empty flag, sorted so the error is stable between runs.
+func required(values map[string]string) error {
+	var missing []string
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if values[name] == "" {
+			missing = append(missing, "-"+name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
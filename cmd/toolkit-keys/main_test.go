@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hiendaovinh/toolkit/pkg/cert"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequired(t *testing.T) {
+	err := required(map[string]string{"pass": "", "out": "", "newpass": "pass:x"})
+	assert.EqualError(t, err, "missing -out, -pass")
+
+	assert.Nil(t, required(map[string]string{"pass": "pass:x"}))
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, keyType := range []cert.KeyType{cert.KeyTypeED25519, cert.KeyTypeECDSAP256} {
+		t.Run(string(keyType), func(t *testing.T) {
+			dir := t.TempDir()
+			pemPath := filepath.Join(dir, "key.pem")
+			jwkPath := filepath.Join(dir, "key.jwk")
+			outPath := filepath.Join(dir, "out.pem")
+
+			var stdout bytes.Buffer
+			err := runGenerate([]string{"-type", string(keyType), "-out", pemPath, "-pass", "pass:123456"}, &stdout)
+			assert.Nil(t, err)
+
+			err = runPEM2JWK([]string{"-in", pemPath, "-pass", "pass:123456"}, &stdout)
+			assert.Nil(t, err)
+			assert.Nil(t, os.WriteFile(jwkPath, stdout.Bytes(), 0o600))
+
+			err = runJWK2PEM([]string{"-in", jwkPath, "-out", outPath, "-newpass", "pass:654321", "-kdf", "scrypt"}, &stdout)
+			assert.Nil(t, err)
+
+			original, err := os.ReadFile(pemPath)
+			assert.Nil(t, err)
+			signer, err := cert.LoadPair(original, []byte(`123456`))
+			assert.Nil(t, err)
+
+			converted, err := os.ReadFile(outPath)
+			assert.Nil(t, err)
+			signerR, err := cert.LoadPair(converted, []byte(`654321`))
+			assert.Nil(t, err)
+			assert.Equal(t, signer, signerR)
+
+			_, err = cert.LoadPair(converted, []byte(`123456`))
+			assert.ErrorIs(t, err, cert.ErrInvalidKeyPair)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+)
+
+// Authorizer is implemented by guard.Guard.
+type Authorizer interface {
+	AllowContext(ctx context.Context, sub string, resource string, action string, c map[string]any) error
+}
+
+// ActionFromMethod maps an HTTP method to a CRUD action, other methods are lowercased.
+func ActionFromMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return "read"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut, http.MethodPatch:
+		return "update"
+	case http.MethodDelete:
+		return "delete"
+	}
+
+	return strings.ToLower(method)
+}
+
+// ExpandResource fills the {name} placeholders of template, e.g. articles:{id}, with param(name).
+func ExpandResource(template string, param func(name string) string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start == -1 {
+			b.WriteString(template)
+			return b.String(), nil
+		}
+
+		end := strings.IndexByte(template[start:], '}')
+		if end == -1 {
+			return "", fmt.Errorf("invalid resource template %q", template)
+		}
+
+		name := template[start+1 : start+end]
+		v := param(name)
+		if v == "" {
+			return "", fmt.Errorf("missing resource param %q", name)
+		}
+
+		b.WriteString(template[:start])
+		b.WriteString(v)
+		template = template[start+end+1:]
+	}
+}
+
+// Authorize checks the subject of ctx, see ResolveSubject, and classifies the failure for the httpx packages.
+func Authorize(ctx context.Context, authorizer Authorizer, resource string, action string, c map[string]any) error {
+	sub, err := ResolveValidSubject(ctx)
+	if err != nil {
+		return errorx.Wrap(err, errorx.Authn)
+	}
+
+	err = authorizer.AllowContext(ctx, sub, resource, action, c)
+	if err == nil {
+		return nil
+	}
+
+	if errorx.IsForbidden(err) {
+		return errorx.Wrap(err, errorx.Authz)
+	}
+
+	var target *errorx.Error
+	if errors.As(err, &target) {
+		return err
+	}
+
+	return errorx.Wrap(err, errorx.Service)
+}
@@ -0,0 +1,82 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hiendaovinh/toolkit/pkg/auth"
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandResource(t *testing.T) {
+	params := map[string]string{"id": "1", "comment": "2"}
+	param := func(name string) string { return params[name] }
+
+	r, err := auth.ExpandResource("articles:{id}:comments:{comment}", param)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, "articles:1:comments:2", r)
+
+	r, err = auth.ExpandResource("articles", param)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, "articles", r)
+
+	_, err = auth.ExpandResource("articles:{slug}", param)
+	assert.NotNil(t, err)
+
+	_, err = auth.ExpandResource("articles:{id", param)
+	assert.NotNil(t, err)
+}
+
+func TestActionFromMethod(t *testing.T) {
+	assert.Equal(t, "read", auth.ActionFromMethod(http.MethodGet))
+	assert.Equal(t, "create", auth.ActionFromMethod(http.MethodPost))
+	assert.Equal(t, "update", auth.ActionFromMethod(http.MethodPatch))
+	assert.Equal(t, "delete", auth.ActionFromMethod(http.MethodDelete))
+	assert.Equal(t, "options", auth.ActionFromMethod(http.MethodOptions))
+}
+
+type authorizerFunc func(sub string, resource string, action string) error
+
+func (f authorizerFunc) AllowContext(ctx context.Context, sub string, resource string, action string, c map[string]any) error {
+	return f(sub, resource, action)
+}
+
+func TestAuthorize(t *testing.T) {
+	authorizer := authorizerFunc(func(sub string, resource string, action string) error {
+		switch {
+		case sub == "foo":
+			return nil
+		case sub == "bar":
+			return ladon.ErrRequestDenied
+		}
+
+		return errors.New("unavailable")
+	})
+
+	var target *errorx.Error
+
+	err := auth.Authorize(context.Background(), authorizer, "articles:1", "read", nil)
+	assert.ErrorAs(t, err, &target)
+	assert.True(t, target.Of(errorx.Authn))
+
+	withSubject := func(sub string) context.Context {
+		return auth.WithAuthClaims(context.Background(), &jwtx.JWTClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: sub}})
+	}
+
+	err = auth.Authorize(withSubject("foo"), authorizer, "articles:1", "read", nil)
+	assert.Nil(t, err, "should be successful")
+
+	err = auth.Authorize(withSubject("bar"), authorizer, "articles:1", "read", nil)
+	assert.ErrorAs(t, err, &target)
+	assert.True(t, target.Of(errorx.Authz))
+
+	err = auth.Authorize(withSubject("qux"), authorizer, "articles:1", "read", nil)
+	assert.ErrorAs(t, err, &target)
+	assert.True(t, target.Of(errorx.Service))
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+)
+
+// An Extractor returns the token of the request, empty when there is none.
+type Extractor func(r *http.Request) string
+
+// FromAuthorizationHeader reads "Authorization: <scheme> <token>", the scheme is case-insensitive.
+func FromAuthorizationHeader(scheme string) Extractor {
+	return func(r *http.Request) string {
+		v, token, ok := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+		if !ok || !strings.EqualFold(v, scheme) {
+			return ""
+		}
+
+		return strings.TrimSpace(token)
+	}
+}
+
+// FromHeader reads the whole value of a custom header, e.g. X-Access-Token.
+func FromHeader(name string) Extractor {
+	return func(r *http.Request) string {
+		return strings.TrimSpace(r.Header.Get(name))
+	}
+}
+
+func FromCookie(name string) Extractor {
+	return func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+
+		return cookie.Value
+	}
+}
+
+// FromQuery reads a query parameter, meant for websocket upgrades where browsers can not set headers.
+func FromQuery(name string) Extractor {
+	return func(r *http.Request) string {
+		return r.URL.Query().Get(name)
+	}
+}
+
+// FirstOf returns the token of the first extractor finding one.
+func FirstOf(extractors ...Extractor) Extractor {
+	return func(r *http.Request) string {
+		for _, extract := range extractors {
+			if token := extract(r); token != "" {
+				return token
+			}
+		}
+
+		return ""
+	}
+}
+
+var DefaultExtractor = FromAuthorizationHeader("Bearer")
+
+type AuthnOptions struct {
+	// Extractor defaults to DefaultExtractor.
+	Extractor Extractor
+	// Required rejects requests without a token instead of passing them on anonymously.
+	Required bool
+}
+
+type Authenticator func(ctx context.Context, tokenStr string) (*jwt.Token, *jwtx.JWTClaims, error)
+
+// Authenticate returns the context of the request carrying the token and its claims.
+// Without a token the context is returned as is, unless opts.Required.
+func Authenticate(r *http.Request, authenticate Authenticator, opts AuthnOptions) (context.Context, error) {
+	extract := opts.Extractor
+	if extract == nil {
+		extract = DefaultExtractor
+	}
+
+	ctx := r.Context()
+	token := extract(r)
+	if token == "" {
+		if opts.Required {
+			return nil, errorx.Wrap(errors.New("missing access token"), errorx.Authn)
+		}
+
+		return ctx, nil
+	}
+
+	_, claims, err := authenticate(ctx, token)
+	if err != nil {
+		// although it's a client error, we don't want to detailed information
+		return nil, errorx.Wrap(errors.New("invalid access token"), errorx.Authn)
+	}
+
+	ctx = WithAuthJWT(ctx, token)
+	ctx = WithAuthClaims(ctx, claims)
+	return ctx, nil
+}
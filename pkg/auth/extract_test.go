@@ -0,0 +1,85 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hiendaovinh/toolkit/pkg/auth"
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractors(t *testing.T) {
+	bearer := auth.FromAuthorizationHeader("Bearer")
+
+	tests := []struct {
+		header string
+		token  string
+	}{
+		{"Bearer abc", "abc"},
+		{"bearer abc", "abc"},
+		{"BEARER  abc ", "abc"},
+		{"Bearer xBearery", "xBearery"},
+		{"Basic abc", ""},
+		{"Bearer", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", tt.header)
+		assert.Equal(t, tt.token, bearer(r), tt.header)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/ws?access_token=query", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: "cookie"})
+	r.Header.Set("X-Access-Token", "header")
+
+	assert.Equal(t, "query", auth.FromQuery("access_token")(r))
+	assert.Equal(t, "cookie", auth.FromCookie("session")(r))
+	assert.Equal(t, "header", auth.FromHeader("X-Access-Token")(r))
+	assert.Equal(t, "", auth.FromCookie("missing")(r))
+
+	chain := auth.FirstOf(bearer, auth.FromCookie("session"), auth.FromQuery("access_token"))
+	assert.Equal(t, "cookie", chain(r))
+
+	r.Header.Set("Authorization", "Bearer first")
+	assert.Equal(t, "first", chain(r))
+}
+
+func TestAuthenticate(t *testing.T) {
+	authenticate := func(ctx context.Context, tokenStr string) (*jwt.Token, *jwtx.JWTClaims, error) {
+		if tokenStr != "valid" {
+			return nil, nil, errors.New("invalid")
+		}
+
+		return nil, &jwtx.JWTClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "foo"}}, nil
+	}
+
+	var target *errorx.Error
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx, err := auth.Authenticate(r, authenticate, auth.AuthnOptions{})
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, "", auth.ResolveSubject(ctx))
+
+	_, err = auth.Authenticate(r, authenticate, auth.AuthnOptions{Required: true})
+	assert.ErrorAs(t, err, &target)
+	assert.True(t, target.Of(errorx.Authn))
+
+	r.Header.Set("Authorization", "Bearer invalid")
+	_, err = auth.Authenticate(r, authenticate, auth.AuthnOptions{})
+	assert.ErrorAs(t, err, &target)
+	assert.True(t, target.Of(errorx.Authn))
+
+	r.Header.Set("Authorization", "bearer valid")
+	ctx, err = auth.Authenticate(r, authenticate, auth.AuthnOptions{Required: true})
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, "foo", auth.ResolveSubject(ctx))
+	assert.Equal(t, "valid", auth.ResolveJWT(ctx))
+}
@@ -42,6 +42,11 @@ func ResolveJWT(ctx context.Context) string {
 	return jwt
 }
 
+func ResolveClaims(ctx context.Context) (*jwtx.JWTClaims, bool) {
+	claims, ok := ctx.Value(ctxKeyAuthClaims).(*jwtx.JWTClaims)
+	return claims, ok
+}
+
 func ResolveSubject(ctx context.Context) string {
 	claims, ok := ctx.Value(ctxKeyAuthClaims).(*jwtx.JWTClaims)
 	if !ok {
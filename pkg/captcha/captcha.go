@@ -0,0 +1,180 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+)
+
+const (
+	EndpointRecaptcha = "https://www.google.com/recaptcha/api/siteverify"
+	EndpointHCaptcha  = "https://api.hcaptcha.com/siteverify"
+	EndpointTurnstile = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+)
+
+var (
+	ErrFailed   = errors.New("captcha failed")
+	ErrExpired  = errors.New("captcha expired")
+	ErrHostname = errors.New("captcha hostname mismatch")
+	ErrAction   = errors.New("captcha action mismatch")
+	ErrScore    = errors.New("captcha score too low")
+)
+
+// DefaultClient is used when Options.Client is nil, unlike http.DefaultClient it times out.
+var DefaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// Response is the siteverify response, the fields are shared by the providers.
+type Response struct {
+	Success     bool      `json:"success"`
+	Score       float64   `json:"score"`
+	Action      string    `json:"action"`
+	ChallengeTS time.Time `json:"challenge_ts"`
+	Hostname    string    `json:"hostname"`
+	ErrorCodes  []string  `json:"error-codes"`
+}
+
+// A Verifier checks the token posted by the client widget.
+// Failures are errorx.Captcha errors, errorx.Service when the provider is unreachable.
+type Verifier interface {
+	Verify(ctx context.Context, token string) (*Response, error)
+}
+
+type Options struct {
+	Secret string
+	// Endpoint defaults to the endpoint of the provider, e.g. an httptest server in tests.
+	Endpoint string
+	// Client defaults to DefaultClient.
+	Client *http.Client
+	// Hostnames are the sites allowed to solve the challenge, empty allows any.
+	Hostnames []string
+	// MaxAge rejects challenges solved earlier, zero disables the check.
+	MaxAge time.Duration
+	// Action is the expected action of reCAPTCHA v3 and Turnstile, ignored otherwise.
+	Action string
+	// Score is the minimum reCAPTCHA v3 score.
+	Score float64
+}
+
+type provider struct {
+	endpoint string
+	// scored responses carry an action and a score, i.e. reCAPTCHA v3.
+	scored bool
+	action bool
+}
+
+// SiteVerify implements the siteverify protocol shared by reCAPTCHA, hCaptcha and Turnstile.
+type SiteVerify struct {
+	opts     Options
+	provider provider
+}
+
+func newSiteVerify(opts Options, p provider) (*SiteVerify, error) {
+	if opts.Secret == "" {
+		return nil, errors.New("invalid secret")
+	}
+
+	if opts.Endpoint == "" {
+		opts.Endpoint = p.endpoint
+	}
+
+	if opts.Client == nil {
+		opts.Client = DefaultClient
+	}
+
+	return &SiteVerify{opts, p}, nil
+}
+
+func NewRecaptchaV2(opts Options) (*SiteVerify, error) {
+	return newSiteVerify(opts, provider{endpoint: EndpointRecaptcha})
+}
+
+func NewRecaptchaV3(opts Options) (*SiteVerify, error) {
+	return newSiteVerify(opts, provider{endpoint: EndpointRecaptcha, scored: true, action: true})
+}
+
+// NewHCaptcha ignores opts.Score, the enterprise score of hCaptcha is a risk score.
+func NewHCaptcha(opts Options) (*SiteVerify, error) {
+	return newSiteVerify(opts, provider{endpoint: EndpointHCaptcha})
+}
+
+func NewTurnstile(opts Options) (*SiteVerify, error) {
+	return newSiteVerify(opts, provider{endpoint: EndpointTurnstile, action: true})
+}
+
+func (v *SiteVerify) Verify(ctx context.Context, token string) (*Response, error) {
+	if token == "" {
+		return nil, errorx.Wrap(ErrFailed, errorx.Captcha)
+	}
+
+	form := url.Values{
+		"secret":   {v.opts.Secret},
+		"response": {token},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.opts.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errorx.Wrap(err, errorx.Service)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.opts.Client.Do(req)
+	if err != nil {
+		return nil, errorx.Wrap(err, errorx.Service)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorx.Wrap(fmt.Errorf("siteverify: unexpected status %d", resp.StatusCode), errorx.Service)
+	}
+
+	var body Response
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, errorx.Wrap(err, errorx.Service)
+	}
+
+	if err := v.check(&body); err != nil {
+		return &body, errorx.Wrap(err, errorx.Captcha)
+	}
+
+	return &body, nil
+}
+
+func (v *SiteVerify) check(body *Response) error {
+	if !body.Success {
+		if len(body.ErrorCodes) > 0 {
+			return fmt.Errorf("%w: %s", ErrFailed, strings.Join(body.ErrorCodes, ", "))
+		}
+
+		return ErrFailed
+	}
+
+	if v.provider.scored && body.Action == "" {
+		return ErrAction
+	}
+
+	if v.provider.action && v.opts.Action != "" && body.Action != v.opts.Action {
+		return ErrAction
+	}
+
+	if v.provider.scored && (body.Score <= 0 || body.Score < v.opts.Score) {
+		return ErrScore
+	}
+
+	if len(v.opts.Hostnames) > 0 && !slices.Contains(v.opts.Hostnames, body.Hostname) {
+		return ErrHostname
+	}
+
+	if v.opts.MaxAge > 0 && (body.ChallengeTS.IsZero() || time.Since(body.ChallengeTS) > v.opts.MaxAge) {
+		return ErrExpired
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package captcha_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hiendaovinh/toolkit/pkg/captcha"
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+	"github.com/stretchr/testify/assert"
+)
+
+// siteverify answers with the response registered for the posted token.
+func siteverify(t *testing.T, responses map[string]captcha.Response) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.PostFormValue("secret"))
+
+		response, ok := responses[r.PostFormValue("response")]
+		if !ok {
+			response = captcha.Response{ErrorCodes: []string{"invalid-input-response"}}
+		}
+
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func assertKind(t *testing.T, err error, kind errorx.Kind) {
+	var target *errorx.Error
+	if assert.ErrorAs(t, err, &target) {
+		assert.True(t, target.Of(kind), "unexpected kind %s", target.Code())
+	}
+}
+
+func TestRecaptchaV3(t *testing.T) {
+	now := time.Now()
+	server := siteverify(t, map[string]captcha.Response{
+		"human": {Success: true, Score: 0.9, Action: "login", ChallengeTS: now, Hostname: "example.com"},
+		"bot":   {Success: true, Score: 0.1, Action: "login", ChallengeTS: now, Hostname: "example.com"},
+		"other": {Success: true, Score: 0.9, Action: "signup", ChallengeTS: now, Hostname: "example.com"},
+		"stale": {Success: true, Score: 0.9, Action: "login", ChallengeTS: now.Add(-time.Hour), Hostname: "example.com"},
+		"phish": {Success: true, Score: 0.9, Action: "login", ChallengeTS: now, Hostname: "example.org"},
+		"blank": {Success: true, Score: 0.9, ChallengeTS: now, Hostname: "example.com"},
+	})
+
+	v, err := captcha.NewRecaptchaV3(captcha.Options{
+		Secret:    "secret",
+		Endpoint:  server.URL,
+		Action:    "login",
+		Score:     0.5,
+		Hostnames: []string{"example.com"},
+		MaxAge:    time.Minute,
+	})
+	assert.Nil(t, err, "should be successful")
+
+	ctx := context.Background()
+	resp, err := v.Verify(ctx, "human")
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, 0.9, resp.Score)
+
+	for token, expected := range map[string]error{
+		"blank": captcha.ErrAction,
+		"bot":   captcha.ErrScore,
+		"other": captcha.ErrAction,
+		"stale": captcha.ErrExpired,
+		"phish": captcha.ErrHostname,
+		"nope":  captcha.ErrFailed,
+		"":      captcha.ErrFailed,
+	} {
+		_, err := v.Verify(ctx, token)
+		assert.ErrorIs(t, err, expected, token)
+		assertKind(t, err, errorx.Captcha)
+	}
+}
+
+func TestProviders(t *testing.T) {
+	server := siteverify(t, map[string]captcha.Response{
+		"token": {Success: true, Action: "login", Hostname: "example.com"},
+	})
+
+	ctx := context.Background()
+	for name, build := range map[string]func(captcha.Options) (*captcha.SiteVerify, error){
+		"recaptcha v2": captcha.NewRecaptchaV2,
+		"hcaptcha":     captcha.NewHCaptcha,
+		"turnstile":    captcha.NewTurnstile,
+	} {
+		_, err := build(captcha.Options{})
+		assert.NotNil(t, err, name)
+
+		v, err := build(captcha.Options{Secret: "secret", Endpoint: server.URL})
+		assert.Nil(t, err, name)
+
+		_, err = v.Verify(ctx, "token")
+		assert.Nil(t, err, name)
+
+		_, err = v.Verify(ctx, "nope")
+		assert.ErrorIs(t, err, captcha.ErrFailed, name)
+	}
+
+	v, err := captcha.NewTurnstile(captcha.Options{Secret: "secret", Endpoint: server.URL, Action: "signup"})
+	assert.Nil(t, err, "should be successful")
+
+	_, err = v.Verify(ctx, "token")
+	assert.ErrorIs(t, err, captcha.ErrAction)
+}
+
+func TestUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	t.Cleanup(server.Close)
+
+	v, err := captcha.NewHCaptcha(captcha.Options{
+		Secret:   "secret",
+		Endpoint: server.URL,
+		Client:   &http.Client{Timeout: 10 * time.Millisecond},
+	})
+	assert.Nil(t, err, "should be successful")
+
+	_, err = v.Verify(context.Background(), "token")
+	assertKind(t, err, errorx.Service)
+}
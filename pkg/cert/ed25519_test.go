@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"testing"
 
 	"github.com/hiendaovinh/toolkit/pkg/cert"
@@ -46,3 +47,113 @@ func TestED25519FromJWK(t *testing.T) {
 	pubString := base64.RawURLEncoding.EncodeToString(pub)
 	assert.Equal(t, "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo", pubString)
 }
+
+func TestMarshalPKCS8(t *testing.T) {
+	password := []byte(`123456`)
+
+	for _, keyType := range []cert.KeyType{cert.KeyTypeED25519, cert.KeyTypeRSA2048, cert.KeyTypeECDSAP256, cert.KeyTypeECDSAP384} {
+		priv, err := cert.GenerateKey(keyType)
+		assert.Nil(t, err, "should be successful")
+
+		pemPUB, pemPRIV, err := cert.MarshalPKCS8(priv, password)
+		assert.Nil(t, err, "should be successful")
+
+		privR, err := cert.LoadPair(append(pem.EncodeToMemory(pemPUB), pem.EncodeToMemory(pemPRIV)...), password)
+		assert.Nil(t, err, "should be successful")
+		assert.Equal(t, priv, privR)
+
+		keyTypeR, err := cert.KeyTypeOf(privR)
+		assert.Nil(t, err, "should be successful")
+		assert.Equal(t, keyType, keyTypeR)
+
+		_, err = cert.UnmarshalPKCS8(pemPRIV, []byte(`654321`))
+		assert.ErrorIs(t, err, cert.ErrInvalidKeyPair)
+	}
+
+	_, err := cert.GenerateKey("ecdsa-p521")
+	assert.ErrorIs(t, err, cert.ErrUnsupportedKey)
+}
+
+func TestLoadPairMismatch(t *testing.T) {
+	password := []byte(`123456`)
+
+	a, err := cert.GenerateKey(cert.KeyTypeECDSAP256)
+	assert.Nil(t, err)
+
+	b, err := cert.GenerateKey(cert.KeyTypeECDSAP256)
+	assert.Nil(t, err)
+
+	pemPUB, _, err := cert.MarshalPKCS8(a, password)
+	assert.Nil(t, err)
+
+	_, pemPRIV, err := cert.MarshalPKCS8(b, password)
+	assert.Nil(t, err)
+
+	_, err = cert.LoadPair(append(pem.EncodeToMemory(pemPUB), pem.EncodeToMemory(pemPRIV)...), password)
+	assert.ErrorIs(t, err, cert.ErrInvalidKeyPair)
+}
+
+func TestED25519JWK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err, "should be successful")
+
+	jwk, err := cert.ED25519PrivateJWK(priv)
+	assert.Nil(t, err, "should be successful")
+
+	id, err := cert.KeyID(pub)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, id, jwk.Kid)
+
+	pubR, privR, err := cert.ED25519FromJWK(jwk)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, pub, pubR)
+	assert.Equal(t, priv, privR)
+
+	// the JSON is readable by other JWK implementations
+	b, err := cert.MarshalED25519PrivateJWKS(priv)
+	assert.Nil(t, err, "should be successful")
+
+	var set struct {
+		Keys []hydra.JSONWebKey `json:"keys"`
+	}
+	err = json.Unmarshal(b, &set)
+	assert.Nil(t, err, "should be successful")
+	assert.Len(t, set.Keys, 1)
+	assert.Equal(t, jwk.Kid, set.Keys[0].Kid)
+
+	pubR, privR, err = cert.ED25519FromJWK(&set.Keys[0])
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, pub, pubR)
+	assert.Equal(t, priv, privR)
+
+	pubJWK, err := cert.ED25519PublicJWK(pub)
+	assert.Nil(t, err, "should be successful")
+	assert.Empty(t, pubJWK.D)
+	assert.Equal(t, jwk.X, pubJWK.X)
+
+	_, _, err = cert.ED25519FromJWK(pubJWK)
+	assert.ErrorIs(t, err, cert.ErrInvalidKeyPair)
+
+	truncated := jwk
+	truncated.D = jwk.D[:10]
+	_, _, err = cert.ED25519FromJWK(truncated)
+	assert.ErrorIs(t, err, cert.ErrInvalidKeyPair, "truncated d")
+
+	mismatched := jwk
+	mismatched.X = jwk.X[:10]
+	_, _, err = cert.ED25519FromJWK(mismatched)
+	assert.ErrorIs(t, err, cert.ErrInvalidKeyPair, "truncated x")
+
+	other, err := cert.ED25519PrivateJWK(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+	assert.Nil(t, err, "should be successful")
+	mismatched.X = other.X
+	_, _, err = cert.ED25519FromJWK(mismatched)
+	assert.ErrorIs(t, err, cert.ErrInvalidKeyPair, "x of another key")
+
+	b, err = cert.MarshalED25519PublicJWKS(pub)
+	assert.Nil(t, err, "should be successful")
+	assert.NotContains(t, string(b), `"d"`)
+
+	_, err = cert.ED25519PrivateJWK(priv[:10])
+	assert.ErrorIs(t, err, cert.ErrInvalidKeyPair)
+}
@@ -0,0 +1,115 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+)
+
+// KeyID is the kid used by jwtx.Authority: the base64url SHA-256 of the raw ed25519 key,
+// or of the PKIX DER encoding for other keys.
+func KeyID(pub crypto.PublicKey) (string, error) {
+	b, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return "", err
+		}
+
+		b = der
+	}
+
+	hash := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
+
+// JWK is an OKP key (RFC 8037), D is only set for private keys.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	D   string `json:"d,omitempty"`
+}
+
+func (k JWK) GetKty() string { return k.Kty }
+func (k JWK) GetCrv() string { return k.Crv }
+func (k JWK) GetD() string   { return k.D }
+func (k JWK) GetX() string   { return k.X }
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func ED25519PublicJWK(pub ed25519.PublicKey) (JWK, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return JWK{}, ErrInvalidKeyPair
+	}
+
+	id, err := KeyID(pub)
+	if err != nil {
+		return JWK{}, err
+	}
+
+	return JWK{
+		Kty: "OKP",
+		Use: "sig",
+		Alg: "EdDSA",
+		Kid: id,
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(pub),
+	}, nil
+}
+
+func ED25519PrivateJWK(priv ed25519.PrivateKey) (JWK, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return JWK{}, ErrInvalidKeyPair
+	}
+
+	pub, _, err := ed25519Pair(priv)
+	if err != nil {
+		return JWK{}, err
+	}
+
+	jwk, err := ED25519PublicJWK(pub)
+	if err != nil {
+		return JWK{}, err
+	}
+
+	jwk.D = base64.RawURLEncoding.EncodeToString(priv.Seed())
+	return jwk, nil
+}
+
+func MarshalED25519PublicJWKS(pubs ...ed25519.PublicKey) ([]byte, error) {
+	set := JWKS{Keys: make([]JWK, 0, len(pubs))}
+	for _, pub := range pubs {
+		jwk, err := ED25519PublicJWK(pub)
+		if err != nil {
+			return nil, err
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return json.Marshal(set)
+}
+
+// MarshalED25519PrivateJWKS includes the private keys, e.g. to import them into Hydra.
+func MarshalED25519PrivateJWKS(privs ...ed25519.PrivateKey) ([]byte, error) {
+	set := JWKS{Keys: make([]JWK, 0, len(privs))}
+	for _, priv := range privs {
+		jwk, err := ED25519PrivateJWK(priv)
+		if err != nil {
+			return nil, err
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return json.Marshal(set)
+}
@@ -0,0 +1,249 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+type KeyType string
+
+const (
+	KeyTypeED25519   KeyType = "ed25519"
+	KeyTypeRSA2048   KeyType = "rsa-2048"
+	KeyTypeRSA3072   KeyType = "rsa-3072"
+	KeyTypeRSA4096   KeyType = "rsa-4096"
+	KeyTypeECDSAP256 KeyType = "ecdsa-p256"
+	KeyTypeECDSAP384 KeyType = "ecdsa-p384"
+)
+
+var ErrUnsupportedKey = errors.New("unsupported key type")
+
+// KeyTypeOf returns the KeyType of an ed25519, RSA or ECDSA P-256/P-384 key.
+func KeyTypeOf(key crypto.Signer) (KeyType, error) {
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return KeyTypeED25519, nil
+	case *rsa.PrivateKey:
+		switch k.N.BitLen() {
+		case 2048:
+			return KeyTypeRSA2048, nil
+		case 3072:
+			return KeyTypeRSA3072, nil
+		case 4096:
+			return KeyTypeRSA4096, nil
+		}
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return KeyTypeECDSAP256, nil
+		case elliptic.P384():
+			return KeyTypeECDSAP384, nil
+		}
+	}
+
+	return "", ErrUnsupportedKey
+}
+
+func GenerateKey(keyType KeyType) (crypto.Signer, error) {
+	switch keyType {
+	case KeyTypeED25519:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	case KeyTypeRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyTypeRSA3072:
+		return rsa.GenerateKey(rand.Reader, 3072)
+	case KeyTypeRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case KeyTypeECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyTypeECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	}
+
+	return nil, ErrUnsupportedKey
+}
+
+func MarshalPKCS8(priv crypto.Signer, password []byte) (*pem.Block, *pem.Block, error) {
+	return MarshalPKCS8WithOptions(priv, password, DefaultPBES2Options)
+}
+
+// MarshalPKCS8WithOptions returns the PUBLIC KEY block and the ENCRYPTED PRIVATE KEY block of the key.
+func MarshalPKCS8WithOptions(priv crypto.Signer, password []byte, opts PBES2Options) (*pem.Block, *pem.Block, error) {
+	if _, err := KeyTypeOf(priv); err != nil {
+		return nil, nil, err
+	}
+
+	keyPUB, err := x509.MarshalPKIXPublicKey(priv.Public())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pemPUB := &pem.Block{Type: TYPE_PUBLIC, Bytes: keyPUB}
+
+	keyPRIV, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pemPRIVEncrypted, err := EncryptPKCS8(keyPRIV, password, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pemPUB, pemPRIVEncrypted, nil
+}
+
+// UnmarshalPKCS8 reads ENCRYPTED PRIVATE KEY blocks and legacy encrypted PRIVATE KEY blocks
+// (Proc-Type headers written by earlier versions). Unencrypted keys are rejected, see UnmarshalPKCS8Plain.
+func UnmarshalPKCS8(pemPRIV *pem.Block, password []byte) (crypto.Signer, error) {
+	var keyPRIVBytes []byte
+	switch {
+	case pemPRIV.Type == TYPE_ENCRYPTED_PRIVATE:
+		b, err := DecryptPKCS8(pemPRIV, password)
+		if err != nil {
+			return nil, err
+		}
+
+		keyPRIVBytes = b
+	//nolint:staticcheck
+	case x509.IsEncryptedPEMBlock(pemPRIV):
+		//nolint:staticcheck
+		b, err := x509.DecryptPEMBlock(pemPRIV, password)
+		if err != nil {
+			return nil, ErrInvalidKeyPair
+		}
+
+		keyPRIVBytes = b
+	default:
+		return nil, ErrInvalidKeyPair
+	}
+
+	return parsePKCS8(keyPRIVBytes)
+}
+
+// UnmarshalPKCS8Plain reads an unencrypted PRIVATE KEY block, e.g. one produced by openssl genpkey.
+func UnmarshalPKCS8Plain(pemPRIV *pem.Block) (crypto.Signer, error) {
+	//nolint:staticcheck
+	if pemPRIV.Type != TYPE_PRIVATE || x509.IsEncryptedPEMBlock(pemPRIV) {
+		return nil, ErrInvalidKeyPair
+	}
+
+	return parsePKCS8(pemPRIV.Bytes)
+}
+
+func parsePKCS8(keyPRIVBytes []byte) (crypto.Signer, error) {
+	keyPRIV, err := x509.ParsePKCS8PrivateKey(keyPRIVBytes)
+	if err != nil {
+		return nil, ErrInvalidKeyPair
+	}
+
+	priv, ok := keyPRIV.(crypto.Signer)
+	if !ok {
+		return nil, ErrInvalidKeyPair
+	}
+
+	if _, err := KeyTypeOf(priv); err != nil {
+		return nil, err
+	}
+
+	return priv, nil
+}
+
+func GeneratePair(path string, keyType KeyType, password []byte) (*pem.Block, *pem.Block, error) {
+	priv, err := GenerateKey(keyType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pemPUB, pemPRIV, err := MarshalPKCS8(priv, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := WritePair(path, pemPUB, pemPRIV); err != nil {
+		return nil, nil, err
+	}
+
+	return pemPUB, pemPRIV, nil
+}
+
+// WritePair writes both blocks to path, readable by the owner only.
+func WritePair(path string, pemPUB *pem.Block, pemPRIV *pem.Block) error {
+	certWriter, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s for writing: %w", path, err)
+	}
+
+	if err := pem.Encode(certWriter, pemPUB); err != nil {
+		return fmt.Errorf("failed to write data to %s: %w", path, err)
+	}
+
+	if err := pem.Encode(certWriter, pemPRIV); err != nil {
+		return fmt.Errorf("failed to write data to %s: %w", path, err)
+	}
+
+	if err := certWriter.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+
+	return nil
+}
+
+type publicKey interface {
+	Equal(x crypto.PublicKey) bool
+}
+
+// LoadPair reads a pair written by GeneratePair, the public block has to match the private key.
+func LoadPair(b []byte, password []byte) (crypto.Signer, error) {
+	var pemPUB, pemPRIV *pem.Block
+
+	for {
+		block, rest := pem.Decode(b)
+		if block == nil {
+			break
+		}
+
+		b = rest
+
+		if block.Type == TYPE_PUBLIC {
+			pemPUB = block
+			continue
+		}
+
+		if block.Type == TYPE_PRIVATE || block.Type == TYPE_ENCRYPTED_PRIVATE {
+			pemPRIV = block
+			continue
+		}
+	}
+
+	if pemPUB == nil || pemPRIV == nil {
+		return nil, ErrInvalidKeyPair
+	}
+
+	priv, err := UnmarshalPKCS8(pemPRIV, password)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPUB, err := x509.ParsePKIXPublicKey(pemPUB.Bytes)
+	if err != nil {
+		return nil, ErrInvalidKeyPair
+	}
+
+	pub, ok := keyPUB.(publicKey)
+	if !ok || !pub.Equal(priv.Public()) {
+		return nil, ErrInvalidKeyPair
+	}
+
+	return priv, nil
+}
@@ -0,0 +1,301 @@
+package cert
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// PKCS#8 encrypted private keys (RFC 5958) using PBES2 (RFC 8018), readable by `openssl pkcs8`.
+
+const TYPE_ENCRYPTED_PRIVATE = "ENCRYPTED PRIVATE KEY"
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt         = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+var ErrUnsupportedEncryption = errors.New("unsupported private key encryption")
+
+type KDF uint8
+
+const (
+	KDFPBKDF2 KDF = iota
+	KDFScrypt
+)
+
+// PBES2Options tunes the key derivation, the cipher is always AES-256-CBC.
+type PBES2Options struct {
+	KDF KDF
+	// Iterations of PBKDF2-HMAC-SHA256, defaults to 600000.
+	Iterations int
+	// ScryptN, ScryptR and ScryptP default to 2^14, 8 and 1, the largest cost openssl accepts by default.
+	ScryptN int
+	ScryptR int
+	ScryptP int
+}
+
+var DefaultPBES2Options = PBES2Options{
+	KDF:        KDFPBKDF2,
+	Iterations: 600000,
+	ScryptN:    1 << 14,
+	ScryptR:    8,
+	ScryptP:    1,
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+func (opts PBES2Options) withDefaults() PBES2Options {
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultPBES2Options.Iterations
+	}
+
+	if opts.ScryptN <= 0 {
+		opts.ScryptN = DefaultPBES2Options.ScryptN
+	}
+
+	if opts.ScryptR <= 0 {
+		opts.ScryptR = DefaultPBES2Options.ScryptR
+	}
+
+	if opts.ScryptP <= 0 {
+		opts.ScryptP = DefaultPBES2Options.ScryptP
+	}
+
+	return opts
+}
+
+// EncryptPKCS8 wraps a DER encoded PKCS#8 private key into an ENCRYPTED PRIVATE KEY block.
+func EncryptPKCS8(der []byte, password []byte, opts PBES2Options) (*pem.Block, error) {
+	opts = opts.withDefaults()
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	var key []byte
+	var kdf pkix.AlgorithmIdentifier
+	switch opts.KDF {
+	case KDFPBKDF2:
+		key = pbkdf2.Key(password, salt, opts.Iterations, 32, sha256.New)
+		params, err := asn1.Marshal(pbkdf2Params{
+			Salt:           salt,
+			IterationCount: opts.Iterations,
+			PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		kdf = pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: params}}
+	case KDFScrypt:
+		var err error
+		key, err = scrypt.Key(password, salt, opts.ScryptN, opts.ScryptR, opts.ScryptP, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		params, err := asn1.Marshal(scryptParams{
+			Salt:                     salt,
+			CostParameter:            opts.ScryptN,
+			BlockSize:                opts.ScryptR,
+			ParallelizationParameter: opts.ScryptP,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		kdf = pkix.AlgorithmIdentifier{Algorithm: oidScrypt, Parameters: asn1.RawValue{FullBytes: params}}
+	default:
+		return nil, ErrUnsupportedEncryption
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// PKCS#7 padding
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	data := make([]byte, len(der), len(der)+padding)
+	copy(data, der)
+	for i := 0; i < padding; i++ {
+		data = append(data, byte(padding))
+	}
+
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	ivParams, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: kdf,
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParams}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pem.Block{Type: TYPE_ENCRYPTED_PRIVATE, Bytes: b}, nil
+}
+
+// DecryptPKCS8 returns the DER encoded PKCS#8 private key of an ENCRYPTED PRIVATE KEY block.
+// PBKDF2 (HMAC-SHA1 or HMAC-SHA256) and scrypt with AES-CBC are supported.
+func DecryptPKCS8(b *pem.Block, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(b.Bytes, &info); err != nil {
+		return nil, ErrInvalidKeyPair
+	}
+
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, ErrUnsupportedEncryption
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, ErrInvalidKeyPair
+	}
+
+	var keyLen int
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLen = 16
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLen = 24
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLen = 32
+	default:
+		return nil, ErrUnsupportedEncryption
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return nil, ErrInvalidKeyPair
+	}
+
+	key, err := deriveKey(params.KeyDerivationFunc, password, keyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data := info.EncryptedData
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, ErrInvalidKeyPair
+	}
+
+	der := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(der, data)
+
+	// a wrong password usually shows up as a bad padding
+	padding := int(der[len(der)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, ErrInvalidKeyPair
+	}
+
+	for _, v := range der[len(der)-padding:] {
+		if int(v) != padding {
+			return nil, ErrInvalidKeyPair
+		}
+	}
+
+	return der[:len(der)-padding], nil
+}
+
+func deriveKey(kdf pkix.AlgorithmIdentifier, password []byte, keyLen int) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		var params pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, ErrInvalidKeyPair
+		}
+
+		var prf func() hash.Hash
+		switch {
+		case len(params.PRF.Algorithm) == 0, params.PRF.Algorithm.Equal(oidHMACWithSHA1):
+			prf = sha1.New
+		case params.PRF.Algorithm.Equal(oidHMACWithSHA256):
+			prf = sha256.New
+		default:
+			return nil, ErrUnsupportedEncryption
+		}
+
+		if params.KeyLength != 0 && params.KeyLength != keyLen {
+			return nil, ErrInvalidKeyPair
+		}
+
+		return pbkdf2.Key(password, params.Salt, params.IterationCount, keyLen, prf), nil
+	case kdf.Algorithm.Equal(oidScrypt):
+		var params scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, ErrInvalidKeyPair
+		}
+
+		if params.KeyLength != 0 && params.KeyLength != keyLen {
+			return nil, ErrInvalidKeyPair
+		}
+
+		return scrypt.Key(password, params.Salt, params.CostParameter, params.BlockSize, params.ParallelizationParameter, keyLen)
+	}
+
+	return nil, ErrUnsupportedEncryption
+}
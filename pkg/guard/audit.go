@@ -0,0 +1,268 @@
+package guard
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/hiendaovinh/toolkit/pkg/redis_stream"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// DecisionRecord is an authorization decision taken by Guard.AllowContext.
+type DecisionRecord struct {
+	Time     time.Time      `json:"time"`
+	Subject  string         `json:"subject"`
+	Resource string         `json:"resource"`
+	Action   string         `json:"action"`
+	Context  map[string]any `json:"context,omitempty"`
+	Decision Decision       `json:"decision"`
+	// Error is set when the AuthChecker failed, the request was denied.
+	Error string `json:"error,omitempty"`
+}
+
+type DecisionSink interface {
+	LogDecision(ctx context.Context, record DecisionRecord) error
+}
+
+var ErrDecisionDropped = errors.New("decision dropped")
+
+type DecisionLoggerOptions struct {
+	// AllowedSampleRate is the ratio of allowed decisions recorded, from 0 to 1. Denies are always recorded.
+	AllowedSampleRate float64
+	// OnError receives sink failures and ErrDecisionDropped, they never change the decision.
+	OnError func(error)
+	// BufferSize is the number of decisions waiting for the sink, defaults to 1024.
+	// Allowed decisions are dropped while the buffer is full, denies are then written by the request, up to Timeout.
+	BufferSize int
+	// Timeout bounds each write to the sink, defaults to 5s.
+	Timeout time.Duration
+}
+
+type decisionWrite struct {
+	ctx    context.Context
+	record DecisionRecord
+}
+
+// DecisionLogger writes the decisions of a Guard to a sink in the background, see WithDecisionLogger.
+type DecisionLogger struct {
+	sink  DecisionSink
+	opts  DecisionLoggerOptions
+	queue chan decisionWrite
+	ctx   context.Context
+	done  chan struct{}
+}
+
+// NewDecisionLogger drains the buffered decisions until ctx is done, Done is closed once the buffer is flushed.
+// Writes use the values of the request context but not its cancellation.
+func NewDecisionLogger(ctx context.Context, sink DecisionSink, opts DecisionLoggerOptions) (*DecisionLogger, error) {
+	if sink == nil {
+		return nil, errors.New("invalid decision sink")
+	}
+
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1024
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	l := &DecisionLogger{
+		sink:  sink,
+		opts:  opts,
+		queue: make(chan decisionWrite, opts.BufferSize),
+		ctx:   ctx,
+		done:  make(chan struct{}),
+	}
+
+	go l.drain()
+	return l, nil
+}
+
+// WithDecisionLogger records the decisions of AllowContext through logger.
+func WithDecisionLogger(logger *DecisionLogger) Option {
+	return func(guard *Guard) {
+		guard.logger = logger
+	}
+}
+
+// Done is closed when the logger stopped and wrote the buffered decisions.
+func (l *DecisionLogger) Done() <-chan struct{} {
+	return l.done
+}
+
+func (l *DecisionLogger) log(ctx context.Context, record DecisionRecord) {
+	if record.Decision.Allowed && (l.opts.AllowedSampleRate <= 0 || rand.Float64() >= l.opts.AllowedSampleRate) {
+		return
+	}
+
+	record.Time = time.Now()
+	w := decisionWrite{context.WithoutCancel(ctx), record}
+	if l.ctx.Err() == nil {
+		select {
+		case l.queue <- w:
+			return
+		default:
+		}
+	}
+
+	// denies are never dropped, the request writes them itself when the buffer is full or the logger stopped
+	if !record.Decision.Allowed {
+		l.write(w)
+		return
+	}
+
+	l.fail(ErrDecisionDropped)
+}
+
+func (l *DecisionLogger) drain() {
+	defer close(l.done)
+
+	for {
+		select {
+		case w := <-l.queue:
+			l.write(w)
+		case <-l.ctx.Done():
+			for {
+				select {
+				case w := <-l.queue:
+					l.write(w)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (l *DecisionLogger) write(w decisionWrite) {
+	ctx, cancel := context.WithTimeout(w.ctx, l.opts.Timeout)
+	defer cancel()
+
+	if err := l.sink.LogDecision(ctx, w.record); err != nil {
+		l.fail(err)
+	}
+}
+
+func (l *DecisionLogger) fail(err error) {
+	if l.opts.OnError != nil {
+		l.opts.OnError(err)
+	}
+}
+
+type DecisionSinkZap struct {
+	logger *zap.Logger
+}
+
+func NewDecisionSinkZap(logger *zap.Logger) (*DecisionSinkZap, error) {
+	if logger == nil {
+		return nil, errors.New("invalid logger")
+	}
+
+	return &DecisionSinkZap{logger}, nil
+}
+
+func (s *DecisionSinkZap) LogDecision(ctx context.Context, record DecisionRecord) error {
+	fields := []zap.Field{
+		zap.Time("time", record.Time),
+		zap.String("subject", record.Subject),
+		zap.String("resource", record.Resource),
+		zap.String("action", record.Action),
+		zap.Any("context", record.Context),
+		zap.Bool("allowed", record.Decision.Allowed),
+		zap.String("reason", string(record.Decision.Reason)),
+		zap.String("policy_id", record.Decision.PolicyID),
+		zap.String("condition", record.Decision.Condition),
+	}
+
+	switch {
+	case record.Error != "":
+		s.logger.Error("authorization failed", append(fields, zap.String("error", record.Error))...)
+	case record.Decision.Allowed:
+		s.logger.Info("authorization allowed", fields...)
+	default:
+		s.logger.Warn("authorization denied", fields...)
+	}
+
+	return nil
+}
+
+// DecisionSinkRedis appends decisions to a Redis stream, the context is a JSON field.
+type DecisionSinkRedis struct {
+	producer *redis_stream.Producer
+}
+
+func NewDecisionSinkRedis(producer *redis_stream.Producer) (*DecisionSinkRedis, error) {
+	if producer == nil {
+		return nil, errors.New("invalid producer")
+	}
+
+	return &DecisionSinkRedis{producer}, nil
+}
+
+func (s *DecisionSinkRedis) LogDecision(ctx context.Context, record DecisionRecord) error {
+	c, err := json.Marshal(record.Context)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.producer.Write(ctx,
+		redis_stream.WithField("time", record.Time.Format(time.RFC3339Nano)),
+		redis_stream.WithField("subject", record.Subject),
+		redis_stream.WithField("resource", record.Resource),
+		redis_stream.WithField("action", record.Action),
+		redis_stream.WithField("context", c),
+		redis_stream.WithField("allowed", strconv.FormatBool(record.Decision.Allowed)),
+		redis_stream.WithField("reason", string(record.Decision.Reason)),
+		redis_stream.WithField("policy_id", record.Decision.PolicyID),
+		redis_stream.WithField("condition", record.Decision.Condition),
+		redis_stream.WithField("error", record.Error),
+	)
+	return err
+}
+
+//go:embed migrations/decisions.sql
+var migrationDecisions string
+
+// MigrateDecisions creates the guard_decisions table used by DecisionSinkPostgres.
+func MigrateDecisions(ctx context.Context, pool *pgxpool.Pool) error {
+	_, err := pool.Exec(ctx, migrationDecisions)
+	return err
+}
+
+type DecisionSinkPostgres struct {
+	pool *pgxpool.Pool
+}
+
+func NewDecisionSinkPostgres(pool *pgxpool.Pool) (*DecisionSinkPostgres, error) {
+	if pool == nil {
+		return nil, errors.New("invalid postgres pool")
+	}
+
+	return &DecisionSinkPostgres{pool}, nil
+}
+
+func (s *DecisionSinkPostgres) LogDecision(ctx context.Context, record DecisionRecord) error {
+	var c []byte
+	if record.Context != nil {
+		var err error
+		c, err = json.Marshal(record.Context)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO guard_decisions (created_at, subject, resource, action, context, allowed, reason, policy_id, condition, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		record.Time, record.Subject, record.Resource, record.Action, c,
+		record.Decision.Allowed, string(record.Decision.Reason), record.Decision.PolicyID, record.Decision.Condition, record.Error,
+	)
+	return err
+}
@@ -0,0 +1,229 @@
+package guard_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/hiendaovinh/toolkit/pkg/guard"
+	"github.com/hiendaovinh/toolkit/pkg/redis_stream"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type sinkMemory struct {
+	mu      sync.Mutex
+	records []guard.DecisionRecord
+}
+
+func (s *sinkMemory) LogDecision(ctx context.Context, record guard.DecisionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, record)
+	return nil
+}
+
+func auditLadon(t *testing.T) *ladon.Ladon {
+	warden, err := guard.NewLadon(ladon.Policies{
+		&ladon.DefaultPolicy{ID: "read", Subjects: []string{"foo"}, Resources: []string{"bar"}, Actions: []string{"read"}, Effect: ladon.AllowAccess},
+		&ladon.DefaultPolicy{ID: "delete", Subjects: []string{"foo"}, Resources: []string{"bar"}, Actions: []string{"delete"}, Effect: ladon.DenyAccess},
+	})
+	assert.Nil(t, err, "should be successful")
+
+	return warden
+}
+
+// newDecisionLogger returns a logger and a func stopping it once the buffered decisions are written.
+func newDecisionLogger(t *testing.T, sink guard.DecisionSink, opts guard.DecisionLoggerOptions) (*guard.DecisionLogger, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	logger, err := guard.NewDecisionLogger(ctx, sink, opts)
+	assert.Nil(t, err, "should be successful")
+
+	return logger, func() {
+		cancel()
+		<-logger.Done()
+	}
+}
+
+func TestDecisionLogger(t *testing.T) {
+	test := beforeEach(t)
+	ctx := context.Background()
+
+	sink := &sinkMemory{}
+	logger, flush := newDecisionLogger(t, sink, guard.DecisionLoggerOptions{})
+	g, err := guard.NewGuard(test.authn, auditLadon(t), guard.WithDecisionLogger(logger))
+	assert.Nil(t, err, "should be successful")
+
+	// allowed decisions are not sampled by default
+	assert.Nil(t, g.AllowContext(ctx, "foo", "bar", "read", nil))
+	assert.ErrorIs(t, g.AllowContext(ctx, "foo", "bar", "delete", map[string]any{"ip": "127.0.0.1"}), ladon.ErrRequestForcefullyDenied)
+	assert.ErrorIs(t, g.AllowContext(ctx, "foo", "bar", "update", nil), ladon.ErrRequestDenied)
+
+	flush()
+	assert.Len(t, sink.records, 2)
+	record := sink.records[0]
+	assert.Equal(t, "foo", record.Subject)
+	assert.Equal(t, "bar", record.Resource)
+	assert.Equal(t, "delete", record.Action)
+	assert.Equal(t, map[string]any{"ip": "127.0.0.1"}, record.Context)
+	assert.Equal(t, guard.Decision{Reason: guard.ReasonExplicitDeny, PolicyID: "delete"}, record.Decision)
+	assert.False(t, record.Time.IsZero())
+	assert.Equal(t, guard.ReasonNoMatch, sink.records[1].Decision.Reason)
+
+	sink = &sinkMemory{}
+	logger, flush = newDecisionLogger(t, sink, guard.DecisionLoggerOptions{AllowedSampleRate: 1})
+	g, err = guard.NewGuard(test.authn, auditLadon(t), guard.WithDecisionLogger(logger))
+	assert.Nil(t, err, "should be successful")
+
+	assert.Nil(t, g.AllowContext(ctx, "foo", "bar", "read", nil))
+	flush()
+	assert.Len(t, sink.records, 1)
+	assert.Equal(t, "read", sink.records[0].Decision.PolicyID)
+
+	// failures of the checker are recorded as well
+	sink = &sinkMemory{}
+	logger, flush = newDecisionLogger(t, sink, guard.DecisionLoggerOptions{})
+	g, err = guard.NewGuard(test.authn, test.authz, guard.WithDecisionLogger(logger))
+	assert.Nil(t, err, "should be successful")
+
+	assert.NotNil(t, g.AllowContext(ctx, "foo", "bar", "nope", nil))
+	flush()
+	assert.Len(t, sink.records, 1)
+	assert.NotEmpty(t, sink.records[0].Error)
+}
+
+// sinkBlocking holds every write until release is closed.
+type sinkBlocking struct {
+	sinkMemory
+	started chan struct{}
+	release chan struct{}
+	errs    []error
+}
+
+func (s *sinkBlocking) LogDecision(ctx context.Context, record guard.DecisionRecord) error {
+	s.started <- struct{}{}
+	<-s.release
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errs = append(s.errs, ctx.Err())
+	s.records = append(s.records, record)
+	return nil
+}
+
+func TestDecisionLoggerBackground(t *testing.T) {
+	test := beforeEach(t)
+
+	var mu sync.Mutex
+	var errs []error
+	sink := &sinkBlocking{started: make(chan struct{}, 4), release: make(chan struct{})}
+	logger, flush := newDecisionLogger(t, sink, guard.DecisionLoggerOptions{AllowedSampleRate: 1, BufferSize: 1, OnError: func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}})
+	g, err := guard.NewGuard(test.authn, auditLadon(t), guard.WithDecisionLogger(logger))
+	assert.Nil(t, err, "should be successful")
+
+	// the request ctx is canceled as soon as the handler returns
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(t, g.AllowContext(ctx, "foo", "bar", "read", nil))
+	<-sink.started
+	for range 2 {
+		assert.Nil(t, g.AllowContext(ctx, "foo", "bar", "read", nil), "never blocked by the sink")
+	}
+
+	// a deny survives the full buffer, written by the request
+	denied := make(chan error)
+	go func() {
+		denied <- g.AllowContext(ctx, "foo", "bar", "delete", nil)
+	}()
+	<-sink.started
+	cancel()
+
+	close(sink.release)
+	assert.ErrorIs(t, <-denied, ladon.ErrRequestForcefullyDenied)
+	flush()
+
+	// one write in flight, one buffered, the last allowed one dropped
+	assert.Len(t, sink.records, 3)
+	assert.Equal(t, []error{nil, nil, nil}, sink.errs)
+	assert.Equal(t, []error{guard.ErrDecisionDropped}, errs)
+
+	actions := []string{}
+	for _, record := range sink.records {
+		actions = append(actions, record.Action)
+	}
+	assert.ElementsMatch(t, []string{"read", "read", "delete"}, actions)
+}
+
+func TestDecisionLoggerStopped(t *testing.T) {
+	test := beforeEach(t)
+
+	var errs []error
+	sink := &sinkMemory{}
+	logger, flush := newDecisionLogger(t, sink, guard.DecisionLoggerOptions{AllowedSampleRate: 1, OnError: func(err error) {
+		errs = append(errs, err)
+	}})
+	g, err := guard.NewGuard(test.authn, auditLadon(t), guard.WithDecisionLogger(logger))
+	assert.Nil(t, err, "should be successful")
+
+	flush()
+	assert.Nil(t, g.AllowContext(context.Background(), "foo", "bar", "read", nil))
+	assert.NotNil(t, g.AllowContext(context.Background(), "foo", "bar", "delete", nil))
+
+	assert.Len(t, sink.records, 1)
+	assert.Equal(t, "delete", sink.records[0].Action)
+	assert.Equal(t, []error{guard.ErrDecisionDropped}, errs)
+}
+
+func TestDecisionSinkZap(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	sink, err := guard.NewDecisionSinkZap(zap.New(core))
+	assert.Nil(t, err, "should be successful")
+
+	test := beforeEach(t)
+	logger, flush := newDecisionLogger(t, sink, guard.DecisionLoggerOptions{AllowedSampleRate: 1})
+	g, err := guard.NewGuard(test.authn, auditLadon(t), guard.WithDecisionLogger(logger))
+	assert.Nil(t, err, "should be successful")
+
+	assert.Nil(t, g.AllowContext(context.Background(), "foo", "bar", "read", nil))
+	assert.NotNil(t, g.AllowContext(context.Background(), "foo", "bar", "delete", nil))
+
+	flush()
+	entries := logs.All()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	assert.Equal(t, "delete", entries[1].ContextMap()["policy_id"])
+}
+
+func TestDecisionSinkRedis(t *testing.T) {
+	client := testRedis(t)
+	stream := "test:" + t.Name()
+	t.Cleanup(func() { client.Del(context.Background(), stream) })
+
+	sink, err := guard.NewDecisionSinkRedis(redis_stream.NewProducer(client, stream))
+	assert.Nil(t, err, "should be successful")
+
+	test := beforeEach(t)
+	logger, flush := newDecisionLogger(t, sink, guard.DecisionLoggerOptions{})
+	g, err := guard.NewGuard(test.authn, auditLadon(t), guard.WithDecisionLogger(logger))
+	assert.Nil(t, err, "should be successful")
+
+	assert.NotNil(t, g.AllowContext(context.Background(), "foo", "bar", "delete", nil))
+
+	flush()
+	messages, err := client.XRange(context.Background(), stream, "-", "+").Result()
+	assert.Nil(t, err, "should be successful")
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "explicit_deny", messages[0].Values["reason"])
+	assert.Equal(t, "false", messages[0].Values["allowed"])
+}
@@ -0,0 +1,260 @@
+package guard
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/ory/ladon"
+)
+
+var ErrUnsupportedChecker = errors.New("unsupported authz checker")
+
+// Check is a single request of a batch, the subject is shared.
+type Check struct {
+	Resource string
+	Action   string
+	Context  map[string]any
+}
+
+// A BatchExplainer decides many requests of the same subject, looking its policies up once.
+type BatchExplainer interface {
+	ExplainBatch(ctx context.Context, subject string, requests []*ladon.Request) ([]Decision, error)
+}
+
+// An ActionLister lists the actions allowed to r.Subject on r.Resource, r.Action is ignored.
+// Actions granted by a pattern, e.g. <.*>, are returned as the pattern, never an action Allow would deny.
+type ActionLister interface {
+	AllowedActions(ctx context.Context, r *ladon.Request) ([]string, error)
+}
+
+// subjectPolicies returns the policies of the subject, checked against the subject.
+func subjectPolicies(ctx context.Context, warden *ladon.Ladon, subject string) (ladon.Policies, error) {
+	candidates, err := warden.Manager.FindPoliciesForSubject(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := ladonMatcher(warden)
+	policies := make(ladon.Policies, 0, len(candidates))
+	for _, p := range candidates {
+		ok, err := matcher.Matches(p, p.GetSubjects(), subject)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			policies = append(policies, p)
+		}
+	}
+
+	return policies, nil
+}
+
+// ExplainLadonBatch is ExplainLadon over many requests of the same subject.
+func ExplainLadonBatch(ctx context.Context, warden *ladon.Ladon, subject string, requests []*ladon.Request) ([]Decision, error) {
+	policies, err := subjectPolicies(ctx, warden, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := ladonMatcher(warden)
+	decisions := make([]Decision, len(requests))
+	for i, r := range requests {
+		decision, deciders, err := explainPolicies(ctx, matcher, policies, r, true)
+		report(ctx, warden, r, policies, decision, deciders, err)
+		if err != nil {
+			return nil, err
+		}
+
+		decisions[i] = decision
+	}
+
+	return decisions, nil
+}
+
+// LadonAllowedActions enumerates the actions of the allow policies matching the request,
+// each one is decided like a request for it so deny policies are taken into account.
+// A pattern is dropped when a deny policy applies to the subject and resource, it would list denied actions.
+func LadonAllowedActions(ctx context.Context, warden *ladon.Ladon, r *ladon.Request) ([]string, error) {
+	policies, err := subjectPolicies(ctx, warden, r.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := ladonMatcher(warden)
+	patterns := map[string]bool{}
+	denied := false
+	for _, p := range policies {
+		ok, err := matcher.Matches(p, p.GetResources(), r.Resource)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if _, ok := failedCondition(ctx, p, r); !ok {
+			continue
+		}
+
+		if !p.AllowAccess() {
+			denied = true
+			continue
+		}
+
+		for _, action := range p.GetActions() {
+			patterns[action] = patterns[action] || strings.IndexByte(action, p.GetStartDelimiter()) != -1
+		}
+	}
+
+	actions := make([]string, 0, len(patterns))
+	for action, pattern := range patterns {
+		if pattern && denied {
+			continue
+		}
+
+		decision, _, err := explainPolicies(ctx, matcher, policies, &ladon.Request{
+			Subject:  r.Subject,
+			Resource: r.Resource,
+			Action:   action,
+			Context:  r.Context,
+		}, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if decision.Allowed {
+			actions = append(actions, action)
+		}
+	}
+
+	slices.Sort(actions)
+	return actions, nil
+}
+
+func (w *PolicyWatcher) ExplainBatch(ctx context.Context, subject string, requests []*ladon.Request) ([]Decision, error) {
+	return ExplainLadonBatch(ctx, w.current.Load(), subject, requests)
+}
+
+func (w *PolicyWatcher) AllowedActions(ctx context.Context, r *ladon.Request) ([]string, error) {
+	return LadonAllowedActions(ctx, w.current.Load(), r)
+}
+
+// ExplainBatch resolves the roles of the subject once.
+func (rbac *RBAC) ExplainBatch(ctx context.Context, subject string, requests []*ladon.Request) ([]Decision, error) {
+	roles, err := rbac.subjectRoles(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make([]Decision, len(requests))
+	for i, r := range requests {
+		decisions[i], err = rbac.explain(roles, r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return decisions, nil
+}
+
+func (rbac *RBAC) AllowedActions(ctx context.Context, r *ladon.Request) ([]string, error) {
+	roles, err := rbac.subjectRoles(ctx, r.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []string
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			ok, err := rbac.matcher.Matches(rbacPolicy, []string{permission.Resource}, r.Resource)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				actions = append(actions, permission.Action)
+			}
+		}
+	}
+
+	slices.Sort(actions)
+	return slices.Compact(actions), nil
+}
+
+func decideBatch(ctx context.Context, authz AuthChecker, subject string, requests []*ladon.Request) ([]Decision, error) {
+	switch checker := authz.(type) {
+	case BatchExplainer:
+		return checker.ExplainBatch(ctx, subject, requests)
+	case *ladon.Ladon:
+		return ExplainLadonBatch(ctx, checker, subject, requests)
+	}
+
+	decisions := make([]Decision, len(requests))
+	for i, r := range requests {
+		var err error
+		decisions[i], err = decide(ctx, authz, r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return decisions, nil
+}
+
+func newRequests(sub string, checks []Check) []*ladon.Request {
+	requests := make([]*ladon.Request, len(checks))
+	for i, check := range checks {
+		requests[i] = newRequest(sub, check.Resource, check.Action, check.Context)
+	}
+
+	return requests
+}
+
+// DecideBatch is Decide over many checks of the same subject, decisions are in the order of checks.
+func (guard *Guard) DecideBatch(ctx context.Context, sub string, checks []Check) ([]Decision, error) {
+	return decideBatch(ctx, guard.authz, sub, newRequests(sub, checks))
+}
+
+// AllowBatch reports which checks are allowed, e.g. which of the listed articles the subject can edit.
+// An error means the checker failed, not that a check was denied.
+func (guard *Guard) AllowBatch(ctx context.Context, sub string, checks []Check) ([]bool, error) {
+	decisions, err := guard.DecideBatch(ctx, sub, checks)
+	if err != nil {
+		if guard.logger != nil {
+			for _, check := range checks {
+				guard.logger.log(ctx, DecisionRecord{Subject: sub, Resource: check.Resource, Action: check.Action, Context: check.Context, Error: err.Error()})
+			}
+		}
+
+		return nil, err
+	}
+
+	allowed := make([]bool, len(decisions))
+	for i, decision := range decisions {
+		allowed[i] = decision.Allowed
+		if guard.logger != nil {
+			check := checks[i]
+			guard.logger.log(ctx, DecisionRecord{Subject: sub, Resource: check.Resource, Action: check.Action, Context: check.Context, Decision: decision})
+		}
+	}
+
+	return allowed, nil
+}
+
+// AllowedActions lists what the subject can do on the resource, see ActionLister.
+// Returns ErrUnsupportedChecker when the AuthChecker can not enumerate its actions.
+func (guard *Guard) AllowedActions(ctx context.Context, sub string, resource string, c map[string]any) ([]string, error) {
+	r := newRequest(sub, resource, "", c)
+	switch checker := guard.authz.(type) {
+	case ActionLister:
+		return checker.AllowedActions(ctx, r)
+	case *ladon.Ladon:
+		return LadonAllowedActions(ctx, checker, r)
+	}
+
+	return nil, ErrUnsupportedChecker
+}
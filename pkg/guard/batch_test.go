@@ -0,0 +1,179 @@
+package guard_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hiendaovinh/toolkit/pkg/guard"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+)
+
+func batchPolicies(n int) ladon.Policies {
+	policies := ladon.Policies{
+		&ladon.DefaultPolicy{ID: "read", Subjects: []string{"users:<.*>"}, Resources: []string{"articles:<.*>"}, Actions: []string{"read", "list"}, Effect: ladon.AllowAccess},
+		&ladon.DefaultPolicy{ID: "edit", Subjects: []string{"users:1"}, Resources: []string{"articles:<[0-9]*[02468]>"}, Actions: []string{"update", "delete"}, Effect: ladon.AllowAccess},
+		&ladon.DefaultPolicy{ID: "locked", Subjects: []string{"users:<.*>"}, Resources: []string{"articles:10"}, Actions: []string{"delete"}, Effect: ladon.DenyAccess},
+		&ladon.DefaultPolicy{ID: "admin", Subjects: []string{"admin"}, Resources: []string{"<.*>"}, Actions: []string{"<.*>"}, Effect: ladon.AllowAccess},
+	}
+
+	// unrelated policies of other subjects
+	for i := 0; i < n; i++ {
+		policies = append(policies, &ladon.DefaultPolicy{
+			ID:        fmt.Sprintf("noise-%d", i),
+			Subjects:  []string{fmt.Sprintf("services:%d", i)},
+			Resources: []string{fmt.Sprintf("queues:<%d|%d>", i, i+1)},
+			Actions:   []string{"publish"},
+			Effect:    ladon.AllowAccess,
+		})
+	}
+
+	return policies
+}
+
+func batchChecks(n int) []guard.Check {
+	checks := make([]guard.Check, n)
+	for i := range checks {
+		checks[i] = guard.Check{Resource: fmt.Sprintf("articles:%d", i), Action: "update"}
+	}
+
+	return checks
+}
+
+func TestGuardAllowBatch(t *testing.T) {
+	warden, err := guard.NewLadon(batchPolicies(10))
+	assert.Nil(t, err, "should be successful")
+
+	test := beforeEach(t)
+	g, err := guard.NewGuard(test.authn, warden)
+	assert.Nil(t, err, "should be successful")
+
+	ctx := context.Background()
+	checks := append(batchChecks(4), guard.Check{Resource: "articles:10", Action: "delete"})
+	allowed, err := g.AllowBatch(ctx, "users:1", checks)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, []bool{true, false, true, false, false}, allowed)
+
+	// same outcome as one call per check
+	for i, check := range checks {
+		err := g.AllowContext(ctx, "users:1", check.Resource, check.Action, check.Context)
+		assert.Equal(t, allowed[i], err == nil, check.Resource)
+	}
+
+	decisions, err := g.DecideBatch(ctx, "users:1", checks)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, guard.Decision{Reason: guard.ReasonExplicitDeny, PolicyID: "locked"}, decisions[4])
+
+	// the fallback calls the checker once per check
+	g, err = guard.NewGuard(test.authn, test.authz)
+	assert.Nil(t, err, "should be successful")
+
+	allowed, err = g.AllowBatch(ctx, "foo", []guard.Check{{Resource: "bar", Action: "qux"}})
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, []bool{true}, allowed)
+}
+
+func TestGuardAllowedActions(t *testing.T) {
+	warden, err := guard.NewLadon(batchPolicies(10))
+	assert.Nil(t, err, "should be successful")
+
+	test := beforeEach(t)
+	g, err := guard.NewGuard(test.authn, warden)
+	assert.Nil(t, err, "should be successful")
+
+	ctx := context.Background()
+	actions, err := g.AllowedActions(ctx, "users:1", "articles:2", nil)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, []string{"delete", "list", "read", "update"}, actions)
+
+	actions, err = g.AllowedActions(ctx, "users:1", "articles:10", nil)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, []string{"list", "read", "update"}, actions)
+
+	actions, err = g.AllowedActions(ctx, "admin", "articles:10", nil)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, []string{"<.*>"}, actions)
+
+	// a deny behind a pattern drops the pattern, the literal actions are decided one by one
+	err = warden.Manager.Create(ctx, &ladon.DefaultPolicy{ID: "archived", Subjects: []string{"<.*>"}, Resources: []string{"articles:archived"}, Actions: []string{"<update|delete>"}, Effect: ladon.DenyAccess})
+	assert.Nil(t, err, "should be successful")
+
+	actions, err = g.AllowedActions(ctx, "admin", "articles:archived", nil)
+	assert.Nil(t, err, "should be successful")
+	assert.Empty(t, actions)
+	assert.ErrorIs(t, g.AllowContext(ctx, "admin", "articles:archived", "delete", nil), ladon.ErrRequestForcefullyDenied)
+
+	actions, err = g.AllowedActions(ctx, "users:1", "articles:archived", nil)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, []string{"list", "read"}, actions)
+
+	store := guard.NewRoleStoreMemory(
+		guard.Role{Name: "reader", Permissions: []guard.Permission{{Resource: "articles:<.*>", Action: "read"}}},
+		guard.Role{Name: "editor", Permissions: []guard.Permission{{Resource: "articles:<.*>", Action: "update"}}, Inherits: []string{"reader"}},
+	)
+	err = store.Assign(ctx, "users:1", "editor")
+	assert.Nil(t, err, "should be successful")
+
+	rbac, err := guard.NewRBAC(store, guard.RBACOptions{})
+	assert.Nil(t, err, "should be successful")
+
+	g, err = guard.NewGuard(test.authn, rbac)
+	assert.Nil(t, err, "should be successful")
+
+	actions, err = g.AllowedActions(ctx, "users:1", "articles:1", nil)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, []string{"read", "update"}, actions)
+
+	allowed, err := g.AllowBatch(ctx, "users:1", []guard.Check{{Resource: "articles:1", Action: "read"}, {Resource: "articles:1", Action: "delete"}})
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, []bool{true, false}, allowed)
+
+	g, err = guard.NewGuard(test.authn, test.authz)
+	assert.Nil(t, err, "should be successful")
+
+	_, err = g.AllowedActions(ctx, "foo", "bar", nil)
+	assert.ErrorIs(t, err, guard.ErrUnsupportedChecker)
+}
+
+func benchmarkGuard(b *testing.B) *guard.Guard {
+	warden, err := guard.NewLadon(batchPolicies(200))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	g, err := guard.NewGuard(func(*jwt.Token) (any, error) { return nil, nil }, warden)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return g
+}
+
+func BenchmarkGuardAllowRepeated(b *testing.B) {
+	g := benchmarkGuard(b)
+	checks := batchChecks(50)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, check := range checks {
+			//nolint:errcheck
+			g.AllowContext(ctx, "users:1", check.Resource, check.Action, check.Context)
+		}
+	}
+}
+
+func BenchmarkGuardAllowBatch(b *testing.B) {
+	g := benchmarkGuard(b)
+	checks := batchChecks(50)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := g.AllowBatch(ctx, "users:1", checks); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
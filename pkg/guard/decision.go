@@ -0,0 +1,187 @@
+package guard
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ory/ladon"
+)
+
+type Reason string
+
+const (
+	ReasonAllowed Reason = "allowed"
+	// ReasonNoMatch means no policy matched the subject, resource and action.
+	ReasonNoMatch Reason = "no_match"
+	// ReasonExplicitDeny means a deny policy matched, it overrides any allow.
+	ReasonExplicitDeny Reason = "explicit_deny"
+	// ReasonConditionFailed means a policy matched but one of its conditions did not pass.
+	ReasonConditionFailed Reason = "condition_failed"
+)
+
+// Decision explains the outcome of an authorization request.
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  Reason `json:"reason"`
+	// PolicyID is the deciding policy: the deny policy, the first allow policy
+	// or the first policy whose condition failed. Empty on ReasonNoMatch.
+	PolicyID string `json:"policy_id,omitempty"`
+	// Condition is the key of the failed condition on ReasonConditionFailed.
+	Condition string `json:"condition,omitempty"`
+}
+
+// Err returns the error IsAllowed reports for the same outcome.
+func (d Decision) Err() error {
+	switch {
+	case d.Allowed:
+		return nil
+	case d.Reason == ReasonExplicitDeny:
+		return ladon.ErrRequestForcefullyDenied
+	}
+
+	return ladon.ErrRequestDenied
+}
+
+// An Explainer is an AuthChecker able to tell why a request is allowed or denied.
+type Explainer interface {
+	Explain(ctx context.Context, r *ladon.Request) (Decision, error)
+}
+
+// policyMatcher is the matcher of ladon.Ladon.
+type policyMatcher interface {
+	Matches(p ladon.Policy, haystack []string, needle string) (bool, error)
+}
+
+func ladonMatcher(warden *ladon.Ladon) policyMatcher {
+	if warden.Matcher == nil {
+		return ladon.DefaultMatcher
+	}
+
+	return warden.Matcher
+}
+
+// ExplainLadon evaluates the request the same way ladon.Ladon.IsAllowed does,
+// the decision is reported to the AuditLogger and Metric of the warden.
+func ExplainLadon(ctx context.Context, warden *ladon.Ladon, r *ladon.Request) (Decision, error) {
+	policies, err := warden.Manager.FindRequestCandidates(ctx, r)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	decision, deciders, err := explainPolicies(ctx, ladonMatcher(warden), policies, r, false)
+	report(ctx, warden, r, policies, decision, deciders, err)
+	return decision, err
+}
+
+// report calls the hooks ladon.Ladon.DoPoliciesAllow calls for the same outcome.
+func report(ctx context.Context, warden *ladon.Ladon, r *ladon.Request, policies ladon.Policies, decision Decision, deciders ladon.Policies, err error) {
+	audit := warden.AuditLogger
+	if audit == nil {
+		audit = ladon.DefaultAuditLogger
+	}
+
+	metric := warden.Metric
+	if metric == nil {
+		metric = ladon.DefaultMetric
+	}
+
+	switch {
+	case err != nil:
+		go metric.RequestProcessingError(*r, deciders[0], err)
+	case decision.Allowed:
+		audit.LogGrantedAccessRequest(ctx, r, policies, deciders)
+		metric.RequestAllowedBy(*r, deciders)
+	case decision.Reason == ReasonExplicitDeny:
+		audit.LogRejectedAccessRequest(ctx, r, policies, deciders)
+		go metric.RequestDeniedBy(*r, deciders[len(deciders)-1])
+	default:
+		go metric.RequestNoMatch(*r)
+		audit.LogRejectedAccessRequest(ctx, r, policies, deciders)
+	}
+}
+
+// explainPolicies replicates ladon.Ladon.DoPoliciesAllow, subjectMatched skips the subject check
+// of policies already filtered by subject. The deciders are the policies ladon passes to its audit log,
+// on error they hold the policy that failed to match.
+func explainPolicies(ctx context.Context, matcher policyMatcher, policies ladon.Policies, r *ladon.Request, subjectMatched bool) (Decision, ladon.Policies, error) {
+	decision := Decision{Reason: ReasonNoMatch}
+	deciders := ladon.Policies{}
+	for _, p := range policies {
+		ok, err := matchesRequest(matcher, p, r, subjectMatched)
+		if err != nil {
+			return Decision{}, ladon.Policies{p}, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if key, ok := failedCondition(ctx, p, r); !ok {
+			if decision.Reason == ReasonNoMatch {
+				decision = Decision{Reason: ReasonConditionFailed, PolicyID: p.GetID(), Condition: key}
+			}
+
+			continue
+		}
+
+		deciders = append(deciders, p)
+		if !p.AllowAccess() {
+			return Decision{Reason: ReasonExplicitDeny, PolicyID: p.GetID()}, deciders, nil
+		}
+
+		if !decision.Allowed {
+			decision = Decision{Allowed: true, Reason: ReasonAllowed, PolicyID: p.GetID()}
+		}
+	}
+
+	return decision, deciders, nil
+}
+
+// matchesRequest checks the action first, then the subject and the resource, like ladon does.
+func matchesRequest(matcher policyMatcher, p ladon.Policy, r *ladon.Request, subjectMatched bool) (bool, error) {
+	ok, err := matcher.Matches(p, p.GetActions(), r.Action)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if !subjectMatched {
+		ok, err = matcher.Matches(p, p.GetSubjects(), r.Subject)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return matcher.Matches(p, p.GetResources(), r.Resource)
+}
+
+func failedCondition(ctx context.Context, p ladon.Policy, r *ladon.Request) (string, bool) {
+	for key, condition := range p.GetConditions() {
+		if !condition.Fulfills(ctx, r.Context[key], r) {
+			return key, false
+		}
+	}
+
+	return "", true
+}
+
+// decide prefers an Explainer and falls back to the error of IsAllowed, without a PolicyID.
+func decide(ctx context.Context, authz AuthChecker, r *ladon.Request) (Decision, error) {
+	switch checker := authz.(type) {
+	case Explainer:
+		return checker.Explain(ctx, r)
+	case *ladon.Ladon:
+		return ExplainLadon(ctx, checker, r)
+	}
+
+	err := authz.IsAllowed(ctx, r)
+	switch {
+	case err == nil:
+		return Decision{Allowed: true, Reason: ReasonAllowed}, nil
+	case errors.Is(err, ladon.ErrRequestForcefullyDenied):
+		return Decision{Reason: ReasonExplicitDeny}, nil
+	case errors.Is(err, ladon.ErrRequestDenied):
+		return Decision{Reason: ReasonNoMatch}, nil
+	}
+
+	return Decision{}, err
+}
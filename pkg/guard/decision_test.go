@@ -0,0 +1,170 @@
+package guard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hiendaovinh/toolkit/pkg/guard"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuardDecide(t *testing.T) {
+	warden, err := guard.NewLadon(ladon.Policies{
+		&ladon.DefaultPolicy{
+			ID:         "articles-owner",
+			Subjects:   []string{"users:<.*>"},
+			Resources:  []string{"articles:<.*>"},
+			Actions:    []string{"update"},
+			Effect:     ladon.AllowAccess,
+			Conditions: ladon.Conditions{"owner": &ladon.EqualsSubjectCondition{}},
+		},
+		&ladon.DefaultPolicy{
+			ID:        "articles-read",
+			Subjects:  []string{"users:<.*>"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"read"},
+			Effect:    ladon.AllowAccess,
+		},
+		&ladon.DefaultPolicy{
+			ID:        "articles-banned",
+			Subjects:  []string{"users:banned"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"<.*>"},
+			Effect:    ladon.DenyAccess,
+		},
+	})
+	assert.Nil(t, err, "should be successful")
+
+	test := beforeEach(t)
+	g, err := guard.NewGuard(test.authn, warden)
+	assert.Nil(t, err, "should be successful")
+
+	tests := []struct {
+		sub, action string
+		ctx         map[string]any
+		decision    guard.Decision
+		err         error
+	}{
+		{"users:1", "read", nil, guard.Decision{Allowed: true, Reason: guard.ReasonAllowed, PolicyID: "articles-read"}, nil},
+		{"users:1", "update", map[string]any{"owner": "users:1"}, guard.Decision{Allowed: true, Reason: guard.ReasonAllowed, PolicyID: "articles-owner"}, nil},
+		{"users:1", "update", map[string]any{"owner": "users:2"}, guard.Decision{Reason: guard.ReasonConditionFailed, PolicyID: "articles-owner", Condition: "owner"}, ladon.ErrRequestDenied},
+		{"users:1", "delete", nil, guard.Decision{Reason: guard.ReasonNoMatch}, ladon.ErrRequestDenied},
+		{"users:banned", "read", nil, guard.Decision{Reason: guard.ReasonExplicitDeny, PolicyID: "articles-banned"}, ladon.ErrRequestForcefullyDenied},
+	}
+
+	for _, tt := range tests {
+		decision, err := g.Decide(context.Background(), tt.sub, "articles:1", tt.action, tt.ctx)
+		assert.Nil(t, err, "should be successful")
+		assert.Equal(t, tt.decision, decision, tt.sub+" "+tt.action)
+		assert.Equal(t, tt.err, decision.Err())
+
+		// Decide agrees with AllowContext
+		err = g.AllowContext(context.Background(), tt.sub, "articles:1", tt.action, tt.ctx)
+		assert.ErrorIs(t, err, tt.err)
+	}
+}
+
+func TestGuardDecideFallback(t *testing.T) {
+	test := beforeEach(t)
+	g, err := guard.NewGuard(test.authn, test.authz)
+	assert.Nil(t, err, "should be successful")
+
+	decision, err := g.Decide(context.Background(), "foo", "bar", "qux", nil)
+	assert.Nil(t, err, "should be successful")
+	assert.True(t, decision.Allowed)
+
+	// errors other than ladon's are not decisions
+	_, err = g.Decide(context.Background(), "foo", "bar", "nope", nil)
+	assert.NotNil(t, err)
+}
+
+func TestGuardAllowContext(t *testing.T) {
+	test := beforeEach(t)
+	g, err := guard.NewGuard(test.authn, canceled{})
+	assert.Nil(t, err, "should be successful")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	err = g.AllowContext(ctx, "foo", "bar", "qux", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// canceled waits for the request context.
+type canceled struct{}
+
+func (canceled) IsAllowed(ctx context.Context, r *ladon.Request) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type auditRecorder struct {
+	granted, rejected []string
+}
+
+func policyIDs(policies ladon.Policies) string {
+	ids := ""
+	for _, p := range policies {
+		ids += p.GetID() + ","
+	}
+
+	return ids
+}
+
+func (a *auditRecorder) LogRejectedAccessRequest(ctx context.Context, r *ladon.Request, pool ladon.Policies, deciders ladon.Policies) {
+	a.rejected = append(a.rejected, r.Action+":"+policyIDs(deciders))
+}
+
+func (a *auditRecorder) LogGrantedAccessRequest(ctx context.Context, r *ladon.Request, pool ladon.Policies, deciders ladon.Policies) {
+	a.granted = append(a.granted, r.Action+":"+policyIDs(deciders))
+}
+
+func TestExplainLadonMatchesIsAllowed(t *testing.T) {
+	warden, err := guard.NewLadon(ladon.Policies{
+		&ladon.DefaultPolicy{
+			ID:        "read",
+			Subjects:  []string{"users:<.*>"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"read"},
+			Effect:    ladon.AllowAccess,
+		},
+		&ladon.DefaultPolicy{
+			ID:        "no-update",
+			Subjects:  []string{"users:<.*>"},
+			Resources: []string{"articles:locked"},
+			Actions:   []string{"update", "delete"},
+			Effect:    ladon.DenyAccess,
+		},
+	})
+	assert.Nil(t, err, "should be successful")
+
+	requests := []*ladon.Request{
+		{Subject: "users:1", Resource: "articles:1", Action: "read"},
+		{Subject: "users:1", Resource: "articles:locked", Action: "read"},
+		{Subject: "users:1", Resource: "articles:locked", Action: "update"},
+		{Subject: "users:1", Resource: "articles:1", Action: "delete"},
+		{Subject: "users:1", Resource: "articles:locked", Action: "delete"},
+	}
+
+	explained := &auditRecorder{}
+	warden.AuditLogger = explained
+	for _, r := range requests {
+		_, err := guard.ExplainLadon(context.Background(), warden, r)
+		assert.Nil(t, err, "should be successful")
+	}
+
+	// IsAllowed on the same warden, the decisions are compared through an unaudited one
+	allowed := &auditRecorder{}
+	warden.AuditLogger = allowed
+	for _, r := range requests {
+		decision, err := guard.ExplainLadon(context.Background(), &ladon.Ladon{Manager: warden.Manager}, r)
+		assert.Nil(t, err, "should be successful")
+		assert.ErrorIs(t, warden.IsAllowed(context.Background(), r), decision.Err())
+	}
+
+	assert.Equal(t, allowed, explained)
+	assert.Equal(t, []string{"read:read,", "read:read,"}, explained.granted)
+	assert.Equal(t, []string{"update:no-update,", "delete:", "delete:no-update,"}, explained.rejected)
+}
@@ -3,6 +3,7 @@ package guard
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/hiendaovinh/toolkit/pkg/jwtx"
@@ -12,31 +13,125 @@ import (
 type Guard struct {
 	authn jwt.Keyfunc
 	authz AuthChecker
+
+	revocation jwtx.RevocationStore
+	validation jwtx.ValidationOptions
+	logger     *DecisionLogger
 }
 
 type AuthChecker interface {
 	IsAllowed(ctx context.Context, r *ladon.Request) error
 }
 
-func NewGuard(authn jwt.Keyfunc, authz AuthChecker) (*Guard, error) {
+// An Option modifies the Guard.
+type Option func(*Guard)
+
+// WithRevocation makes AuthenticateJWT reject tokens denied by the store.
+func WithRevocation(store jwtx.RevocationStore) Option {
+	return func(guard *Guard) {
+		guard.revocation = store
+	}
+}
+
+// WithValidation sets the claim validation applied by AuthenticateJWT.
+func WithValidation(opts jwtx.ValidationOptions) Option {
+	return func(guard *Guard) {
+		guard.validation = opts
+	}
+}
+
+func NewGuard(authn jwt.Keyfunc, authz AuthChecker, options ...Option) (*Guard, error) {
 	if authn == nil || authz == nil {
 		return nil, errors.New("invalid authn or authz")
 	}
 
-	return &Guard{authn, authz}, nil
+	guard := &Guard{authn: authn, authz: authz}
+	for _, opt := range options {
+		opt(guard)
+	}
+
+	return guard, nil
+}
+
+// NewGuardFromJWKS verifies tokens against a remote JWKS, e.g. one served by jwtx.Authority.PublicJWKS.
+// Keys are refreshed in the background until ctx is done, the key set is returned for health checks.
+func NewGuardFromJWKS(ctx context.Context, url string, authz AuthChecker, options ...Option) (*Guard, *jwtx.RemoteKeySet, error) {
+	keys, err := jwtx.NewRemoteKeySet(ctx, url, jwtx.RemoteKeySetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	guard, err := NewGuard(keys.KeyfuncCtx(ctx), authz, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return guard, keys, nil
 }
 
 func (guard *Guard) Allow(sub string, resource string, action string, ctx map[string]any) error {
-	r := &ladon.Request{
+	return guard.AllowContext(context.Background(), sub, resource, action, ctx)
+}
+
+// AllowContext goes through Decide when a decision logger is set, see WithDecisionLogger.
+func (guard *Guard) AllowContext(ctx context.Context, sub string, resource string, action string, c map[string]any) error {
+	r := newRequest(sub, resource, action, c)
+	if guard.logger == nil {
+		return guard.authz.IsAllowed(ctx, r)
+	}
+
+	decision, err := decide(ctx, guard.authz, r)
+	record := DecisionRecord{Subject: sub, Resource: resource, Action: action, Context: c, Decision: decision}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	guard.logger.log(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	return decision.Err()
+}
+
+// Decide explains the outcome of the request, see Decision.
+// Denied requests are not errors, Decision.Err returns the error AllowContext would.
+func (guard *Guard) Decide(ctx context.Context, sub string, resource string, action string, c map[string]any) (Decision, error) {
+	return decide(ctx, guard.authz, newRequest(sub, resource, action, c))
+}
+
+func newRequest(sub string, resource string, action string, c map[string]any) *ladon.Request {
+	return &ladon.Request{
 		Subject:  sub,
 		Resource: resource,
 		Action:   action,
-		Context:  ctx,
+		Context:  c,
 	}
-
-	return guard.authz.IsAllowed(context.TODO(), r)
 }
 
 func (guard *Guard) AuthenticateJWT(ctx context.Context, tokenStr string) (*jwt.Token, *jwtx.JWTClaims, error) {
-	return jwtx.ValidateToken(ctx, tokenStr, guard.authn)
+	return guard.AuthenticateJWTWithOptions(ctx, tokenStr, guard.validation)
+}
+
+// AuthenticateJWTWithOptions overrides the validation configured on the Guard.
+func (guard *Guard) AuthenticateJWTWithOptions(ctx context.Context, tokenStr string, opts jwtx.ValidationOptions) (*jwt.Token, *jwtx.JWTClaims, error) {
+	token, claims, err := jwtx.ValidateTokenWithOptions(ctx, tokenStr, guard.authn, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if guard.revocation == nil {
+		return token, claims, nil
+	}
+
+	revoked, err := guard.revocation.IsRevoked(ctx, claims)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to check revocation: %w", err)
+	}
+
+	if revoked {
+		return nil, nil, jwtx.ErrTokenRevoked
+	}
+
+	return token, claims, nil
 }
@@ -2,14 +2,17 @@ package guard_test
 
 import (
 	"context"
+	"crypto/ed25519"
 	"errors"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/MicahParks/jwkset"
 	"github.com/MicahParks/keyfunc/v3"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/hiendaovinh/toolkit/pkg/guard"
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
 	"github.com/ory/ladon"
 	"github.com/stretchr/testify/assert"
 )
@@ -82,3 +85,43 @@ func TestGuardAuthz(t *testing.T) {
 	err = guard.Allow(sub, resource, "quxx", ctx)
 	assert.Error(t, err)
 }
+
+func TestGuardRevocation(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("issuer", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	_, jwks, err := a.GenerateKeySet(context.Background())
+	assert.NoError(t, err)
+
+	store := jwtx.NewRevocationStoreMemory()
+	test := beforeEach(t)
+	g, err := guard.NewGuard(jwks.Keyfunc, test.authz, guard.WithRevocation(store))
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	claims, tokenStr, err := a.IssueToken(ctx, "foo", nil, nil)
+	assert.NoError(t, err)
+
+	_, _, err = g.AuthenticateJWT(ctx, tokenStr)
+	assert.NoError(t, err)
+
+	assert.NoError(t, jwtx.RevokeToken(ctx, store, claims))
+	_, _, err = g.AuthenticateJWT(ctx, tokenStr)
+	assert.ErrorIs(t, err, jwtx.ErrTokenRevoked)
+
+	_, tokenStr, err = a.IssueToken(ctx, "foo", nil, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.RevokeSubject(ctx, "foo", time.Now(), time.Now().Add(time.Minute)))
+	_, _, err = g.AuthenticateJWT(ctx, tokenStr)
+	assert.ErrorIs(t, err, jwtx.ErrTokenRevoked)
+
+	_, tokenStr, err = a.IssueToken(ctx, "bar", nil, nil)
+	assert.NoError(t, err)
+
+	_, _, err = g.AuthenticateJWT(ctx, tokenStr)
+	assert.NoError(t, err)
+}
@@ -2,14 +2,21 @@ package guard
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/ory/ladon"
 	manager "github.com/ory/ladon/manager/memory"
+	"github.com/redis/go-redis/v9"
 )
 
+var ErrPolicyExists = errors.New("policy exists")
+
 func NewLadon(policies ladon.Policies) (*ladon.Ladon, error) {
-	warden := &ladon.Ladon{
-		Manager: manager.NewMemoryManager(),
+	warden, err := NewLadonWithManager(manager.NewMemoryManager())
+	if err != nil {
+		return nil, err
 	}
 
 	for _, pol := range policies {
@@ -21,3 +28,72 @@ func NewLadon(policies ladon.Policies) (*ladon.Ladon, error) {
 
 	return warden, nil
 }
+
+// NewLadonWithManager builds the warden used by every NewLadon variant on top of m.
+func NewLadonWithManager(m ladon.Manager) (*ladon.Ladon, error) {
+	if m == nil {
+		return nil, errors.New("invalid policy manager")
+	}
+
+	return &ladon.Ladon{Manager: m}, nil
+}
+
+// NewLadonFromFiles is NewLadon over policy files, see LoadPolicies and NewPolicyWatcher.
+func NewLadonFromFiles(paths ...string) (*ladon.Ladon, error) {
+	policies, err := LoadPolicies(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLadon(policies)
+}
+
+// NewLadonPostgres reads policies from the ladon_policies table, see MigratePolicies.
+// Policies are cached in memory and reloaded when a replica publishes a change on client,
+// manage them through the Manager of the returned Ladon.
+func NewLadonPostgres(ctx context.Context, pool *pgxpool.Pool, client redis.UniversalClient, opts PolicyCacheOptions) (*ladon.Ladon, error) {
+	source, err := NewPolicyManagerPostgres(pool)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := NewPolicyManagerCached(ctx, source, client, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLadonWithManager(cached)
+}
+
+// NewLadonRedis reads policies from a Redis hash, cached the same way as NewLadonPostgres.
+func NewLadonRedis(ctx context.Context, client redis.UniversalClient, prefix string, opts PolicyCacheOptions) (*ladon.Ladon, error) {
+	source, err := NewPolicyManagerRedis(client, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := NewPolicyManagerCached(ctx, source, client, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLadonWithManager(cached)
+}
+
+func unmarshalPolicy(document []byte) (ladon.Policy, error) {
+	var policy ladon.DefaultPolicy
+	if err := json.Unmarshal(document, &policy); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func paginate(limit, offset int64, total int) (int, int) {
+	start := min(max(int(offset), 0), total)
+	if limit <= 0 {
+		return start, total
+	}
+
+	return start, min(start+int(limit), total)
+}
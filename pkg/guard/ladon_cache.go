@@ -0,0 +1,226 @@
+package guard
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/ory/ladon"
+	"github.com/redis/go-redis/v9"
+)
+
+const policyPageSize = 500
+
+type PolicyCacheOptions struct {
+	// Channel carries invalidations between replicas, defaults to guard:policies.
+	Channel string
+	// OnError receives background reload and publish failures, the last loaded policies stay in use.
+	OnError func(error)
+}
+
+// PolicyManagerCached serves reads from memory and writes through to the source manager.
+// Every write is published on a Redis channel so the other replicas reload their copy.
+type PolicyManagerCached struct {
+	source  ladon.Manager
+	client  redis.UniversalClient
+	channel string
+	onError func(error)
+
+	mu       sync.RWMutex
+	policies map[string]ladon.Policy
+}
+
+// NewPolicyManagerCached loads every policy of source and keeps listening for invalidations until ctx is done.
+// Without a client the cache only sees the writes made through it.
+func NewPolicyManagerCached(ctx context.Context, source ladon.Manager, client redis.UniversalClient, opts PolicyCacheOptions) (*PolicyManagerCached, error) {
+	if source == nil {
+		return nil, errors.New("invalid policy manager")
+	}
+
+	if opts.Channel == "" {
+		opts.Channel = "guard:policies"
+	}
+
+	m := &PolicyManagerCached{
+		source:  source,
+		client:  client,
+		channel: opts.Channel,
+		onError: opts.OnError,
+	}
+
+	if client == nil {
+		return m, m.Reload(ctx)
+	}
+
+	pubsub := client.Subscribe(ctx, m.channel)
+	// wait for the subscription so no write between the load and the listen is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	if err := m.Reload(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	go m.listen(ctx, pubsub)
+	return m, nil
+}
+
+func (m *PolicyManagerCached) listen(ctx context.Context, pubsub *redis.PubSub) {
+	defer pubsub.Close()
+
+	// subscriptions are delivered again after a reconnect, messages may have been lost meanwhile
+	ch := pubsub.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			if err := m.Reload(ctx); err != nil {
+				m.fail(err)
+			}
+		}
+	}
+}
+
+func (m *PolicyManagerCached) fail(err error) {
+	if m.onError != nil {
+		m.onError(err)
+	}
+}
+
+// Reload replaces the cached policies with the ones of the source manager.
+func (m *PolicyManagerCached) Reload(ctx context.Context) error {
+	policies := map[string]ladon.Policy{}
+	for offset := int64(0); ; offset += policyPageSize {
+		page, err := m.source.GetAll(ctx, policyPageSize, offset)
+		if err != nil {
+			return err
+		}
+
+		for _, policy := range page {
+			policies[policy.GetID()] = policy
+		}
+
+		if len(page) < policyPageSize {
+			break
+		}
+	}
+
+	m.mu.Lock()
+	m.policies = policies
+	m.mu.Unlock()
+
+	return nil
+}
+
+func (m *PolicyManagerCached) publish(ctx context.Context, id string) {
+	if m.client == nil {
+		return
+	}
+
+	if err := m.client.Publish(ctx, m.channel, id).Err(); err != nil {
+		m.fail(err)
+	}
+}
+
+func (m *PolicyManagerCached) Create(ctx context.Context, policy ladon.Policy) error {
+	if err := m.source.Create(ctx, policy); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.policies[policy.GetID()] = policy
+	m.mu.Unlock()
+
+	m.publish(ctx, policy.GetID())
+	return nil
+}
+
+func (m *PolicyManagerCached) Update(ctx context.Context, policy ladon.Policy) error {
+	if err := m.source.Update(ctx, policy); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.policies[policy.GetID()] = policy
+	m.mu.Unlock()
+
+	m.publish(ctx, policy.GetID())
+	return nil
+}
+
+func (m *PolicyManagerCached) Delete(ctx context.Context, id string) error {
+	if err := m.source.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	delete(m.policies, id)
+	m.mu.Unlock()
+
+	m.publish(ctx, id)
+	return nil
+}
+
+func (m *PolicyManagerCached) Get(ctx context.Context, id string) (ladon.Policy, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	policy, ok := m.policies[id]
+	if !ok {
+		return nil, ladon.ErrNotFound
+	}
+
+	return policy, nil
+}
+
+func (m *PolicyManagerCached) GetAll(ctx context.Context, limit, offset int64) (ladon.Policies, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]string, 0, len(m.policies))
+	for id := range m.policies {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	start, end := paginate(limit, offset, len(ids))
+	policies := make(ladon.Policies, 0, end-start)
+	for _, id := range ids[start:end] {
+		policies = append(policies, m.policies[id])
+	}
+
+	return policies, nil
+}
+
+func (m *PolicyManagerCached) FindRequestCandidates(ctx context.Context, r *ladon.Request) (ladon.Policies, error) {
+	return m.all(), nil
+}
+
+func (m *PolicyManagerCached) FindPoliciesForSubject(ctx context.Context, subject string) (ladon.Policies, error) {
+	return m.all(), nil
+}
+
+func (m *PolicyManagerCached) FindPoliciesForResource(ctx context.Context, resource string) (ladon.Policies, error) {
+	return m.all(), nil
+}
+
+func (m *PolicyManagerCached) all() ladon.Policies {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	policies := make(ladon.Policies, 0, len(m.policies))
+	for _, policy := range m.policies {
+		policies = append(policies, policy)
+	}
+
+	return policies
+}
@@ -0,0 +1,132 @@
+package guard
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ory/ladon"
+)
+
+//go:embed migrations/ladon_policies.sql
+var migrationPolicies string
+
+// MigratePolicies creates the ladon_policies table used by PolicyManagerPostgres.
+func MigratePolicies(ctx context.Context, pool *pgxpool.Pool) error {
+	_, err := pool.Exec(ctx, migrationPolicies)
+	return err
+}
+
+// PolicyManagerPostgres is a ladon.Manager storing policies as JSON documents.
+// Policy patterns are regular expressions, so the Find methods load every policy and leave matching to ladon:
+// use it as the source of NewPolicyManagerCached, as NewLadonPostgres does, rather than as the Manager of a warden.
+type PolicyManagerPostgres struct {
+	pool *pgxpool.Pool
+}
+
+func NewPolicyManagerPostgres(pool *pgxpool.Pool) (*PolicyManagerPostgres, error) {
+	if pool == nil {
+		return nil, errors.New("invalid postgres pool")
+	}
+
+	return &PolicyManagerPostgres{pool}, nil
+}
+
+func (m *PolicyManagerPostgres) Create(ctx context.Context, policy ladon.Policy) error {
+	document, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.pool.Exec(ctx, `INSERT INTO ladon_policies (id, document) VALUES ($1, $2)`, policy.GetID(), document)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrPolicyExists
+	}
+
+	return err
+}
+
+func (m *PolicyManagerPostgres) Update(ctx context.Context, policy ladon.Policy) error {
+	document, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	tag, err := m.pool.Exec(ctx, `UPDATE ladon_policies SET document = $2, updated_at = now() WHERE id = $1`, policy.GetID(), document)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ladon.ErrNotFound
+	}
+
+	return nil
+}
+
+func (m *PolicyManagerPostgres) Get(ctx context.Context, id string) (ladon.Policy, error) {
+	var document []byte
+	err := m.pool.QueryRow(ctx, `SELECT document FROM ladon_policies WHERE id = $1`, id).Scan(&document)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ladon.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalPolicy(document)
+}
+
+func (m *PolicyManagerPostgres) Delete(ctx context.Context, id string) error {
+	_, err := m.pool.Exec(ctx, `DELETE FROM ladon_policies WHERE id = $1`, id)
+	return err
+}
+
+func (m *PolicyManagerPostgres) GetAll(ctx context.Context, limit, offset int64) (ladon.Policies, error) {
+	return m.query(ctx, `SELECT document FROM ladon_policies ORDER BY id LIMIT $1 OFFSET $2`, limit, offset)
+}
+
+// FindRequestCandidates returns every policy, see PolicyManagerPostgres.
+func (m *PolicyManagerPostgres) FindRequestCandidates(ctx context.Context, r *ladon.Request) (ladon.Policies, error) {
+	return m.query(ctx, `SELECT document FROM ladon_policies`)
+}
+
+// FindPoliciesForSubject returns every policy, see PolicyManagerPostgres.
+func (m *PolicyManagerPostgres) FindPoliciesForSubject(ctx context.Context, subject string) (ladon.Policies, error) {
+	return m.query(ctx, `SELECT document FROM ladon_policies`)
+}
+
+// FindPoliciesForResource returns every policy, see PolicyManagerPostgres.
+func (m *PolicyManagerPostgres) FindPoliciesForResource(ctx context.Context, resource string) (ladon.Policies, error) {
+	return m.query(ctx, `SELECT document FROM ladon_policies`)
+}
+
+func (m *PolicyManagerPostgres) query(ctx context.Context, sql string, args ...any) (ladon.Policies, error) {
+	rows, err := m.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := pgx.CollectRows(rows, pgx.RowTo[[]byte])
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make(ladon.Policies, 0, len(documents))
+	for _, document := range documents {
+		policy, err := unmarshalPolicy(document)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
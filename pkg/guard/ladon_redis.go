@@ -0,0 +1,151 @@
+package guard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"github.com/ory/ladon"
+	"github.com/redis/go-redis/v9"
+)
+
+var scriptPolicyUpdate = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+
+// PolicyManagerRedis is a ladon.Manager storing policies as JSON documents in a single hash.
+// Like PolicyManagerPostgres, the Find methods load every policy: use it behind NewPolicyManagerCached, see NewLadonRedis.
+type PolicyManagerRedis struct {
+	client redis.UniversalClient
+	key    string
+}
+
+func NewPolicyManagerRedis(client redis.UniversalClient, prefix string) (*PolicyManagerRedis, error) {
+	if client == nil {
+		return nil, errors.New("invalid redis client")
+	}
+
+	if prefix == "" {
+		prefix = "guard"
+	}
+
+	return &PolicyManagerRedis{client, prefix + ":policies"}, nil
+}
+
+func (m *PolicyManagerRedis) Create(ctx context.Context, policy ladon.Policy) error {
+	document, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	ok, err := m.client.HSetNX(ctx, m.key, policy.GetID(), document).Result()
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrPolicyExists
+	}
+
+	return nil
+}
+
+func (m *PolicyManagerRedis) Update(ctx context.Context, policy ladon.Policy) error {
+	document, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	updated, err := scriptPolicyUpdate.Run(ctx, m.client, []string{m.key}, policy.GetID(), document).Int()
+	if err != nil {
+		return err
+	}
+
+	if updated == 0 {
+		return ladon.ErrNotFound
+	}
+
+	return nil
+}
+
+func (m *PolicyManagerRedis) Get(ctx context.Context, id string) (ladon.Policy, error) {
+	document, err := m.client.HGet(ctx, m.key, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ladon.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalPolicy(document)
+}
+
+func (m *PolicyManagerRedis) Delete(ctx context.Context, id string) error {
+	return m.client.HDel(ctx, m.key, id).Err()
+}
+
+func (m *PolicyManagerRedis) GetAll(ctx context.Context, limit, offset int64) (ladon.Policies, error) {
+	documents, err := m.client.HGetAll(ctx, m.key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(documents))
+	for id := range documents {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	start, end := paginate(limit, offset, len(ids))
+	policies := make(ladon.Policies, 0, end-start)
+	for _, id := range ids[start:end] {
+		policy, err := unmarshalPolicy([]byte(documents[id]))
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// FindRequestCandidates returns every policy, see PolicyManagerRedis.
+func (m *PolicyManagerRedis) FindRequestCandidates(ctx context.Context, r *ladon.Request) (ladon.Policies, error) {
+	return m.all(ctx)
+}
+
+// FindPoliciesForSubject returns every policy, see PolicyManagerRedis.
+func (m *PolicyManagerRedis) FindPoliciesForSubject(ctx context.Context, subject string) (ladon.Policies, error) {
+	return m.all(ctx)
+}
+
+// FindPoliciesForResource returns every policy, see PolicyManagerRedis.
+func (m *PolicyManagerRedis) FindPoliciesForResource(ctx context.Context, resource string) (ladon.Policies, error) {
+	return m.all(ctx)
+}
+
+func (m *PolicyManagerRedis) all(ctx context.Context) (ladon.Policies, error) {
+	documents, err := m.client.HVals(ctx, m.key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make(ladon.Policies, 0, len(documents))
+	for _, document := range documents {
+		policy, err := unmarshalPolicy([]byte(document))
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
@@ -0,0 +1,182 @@
+package guard_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hiendaovinh/toolkit/pkg/db"
+	"github.com/hiendaovinh/toolkit/pkg/guard"
+	"github.com/ory/ladon"
+	manager "github.com/ory/ladon/manager/memory"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func testPolicyManager(t *testing.T, m ladon.Manager) {
+	ctx := context.Background()
+
+	policy := &ladon.DefaultPolicy{
+		ID:        "articles-read",
+		Subjects:  []string{"users:<.*>"},
+		Resources: []string{"articles:<.*>"},
+		Actions:   []string{"read"},
+		Effect:    ladon.AllowAccess,
+		Conditions: ladon.Conditions{
+			"owner": &ladon.EqualsSubjectCondition{},
+		},
+	}
+
+	err := m.Create(ctx, policy)
+	assert.Nil(t, err, "should be successful")
+
+	err = m.Create(ctx, policy)
+	assert.ErrorIs(t, err, guard.ErrPolicyExists)
+
+	got, err := m.Get(ctx, policy.ID)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, policy.Resources, got.GetResources())
+	assert.IsType(t, &ladon.EqualsSubjectCondition{}, got.GetConditions()["owner"])
+
+	policy.Actions = []string{"read", "list"}
+	err = m.Update(ctx, policy)
+	assert.Nil(t, err, "should be successful")
+
+	got, err = m.Get(ctx, policy.ID)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, policy.Actions, got.GetActions())
+
+	err = m.Update(ctx, &ladon.DefaultPolicy{ID: "missing"})
+	assert.ErrorIs(t, err, ladon.ErrNotFound)
+
+	err = m.Create(ctx, &ladon.DefaultPolicy{ID: "articles-write", Subjects: []string{"admin"}, Resources: []string{"articles:<.*>"}, Actions: []string{"write"}, Effect: ladon.AllowAccess})
+	assert.Nil(t, err, "should be successful")
+
+	all, err := m.GetAll(ctx, 1, 1)
+	assert.Nil(t, err, "should be successful")
+	assert.Len(t, all, 1)
+	assert.Equal(t, "articles-write", all[0].GetID())
+
+	warden := &ladon.Ladon{Manager: m}
+	err = warden.IsAllowed(ctx, &ladon.Request{Subject: "users:1", Resource: "articles:1", Action: "list", Context: ladon.Context{"owner": "users:1"}})
+	assert.Nil(t, err, "should be successful")
+
+	err = m.Delete(ctx, policy.ID)
+	assert.Nil(t, err, "should be successful")
+
+	_, err = m.Get(ctx, policy.ID)
+	assert.ErrorIs(t, err, ladon.ErrNotFound)
+
+	err = warden.IsAllowed(ctx, &ladon.Request{Subject: "users:1", Resource: "articles:1", Action: "list", Context: ladon.Context{"owner": "users:1"}})
+	assert.ErrorIs(t, err, ladon.ErrRequestDenied)
+}
+
+type memoryManager struct {
+	*manager.MemoryManager
+}
+
+// Create matches the error of the persistent managers.
+func (m memoryManager) Create(ctx context.Context, policy ladon.Policy) error {
+	if _, err := m.Get(ctx, policy.GetID()); err == nil {
+		return guard.ErrPolicyExists
+	}
+
+	return m.MemoryManager.Create(ctx, policy)
+}
+
+// Update matches the error of the persistent managers.
+func (m memoryManager) Update(ctx context.Context, policy ladon.Policy) error {
+	if _, err := m.Get(ctx, policy.GetID()); err != nil {
+		return ladon.ErrNotFound
+	}
+
+	return m.MemoryManager.Update(ctx, policy)
+}
+
+func TestPolicyManagerCached(t *testing.T) {
+	m, err := guard.NewPolicyManagerCached(context.Background(), memoryManager{manager.NewMemoryManager()}, nil, guard.PolicyCacheOptions{})
+	assert.Nil(t, err, "should be successful")
+
+	testPolicyManager(t, m)
+
+	warden, err := guard.NewLadonWithManager(m)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, m, warden.Manager)
+
+	_, err = guard.NewLadonWithManager(nil)
+	assert.Error(t, err)
+}
+
+func testRedis(t *testing.T) *redis.Client {
+	url := os.Getenv("TOOLKIT_TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TOOLKIT_TEST_REDIS_URL is not set")
+	}
+
+	client, err := db.InitRedis(&db.RedisConfig{URL: url})
+	assert.Nil(t, err, "should be successful")
+
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestPolicyManagerRedis(t *testing.T) {
+	client := testRedis(t)
+	prefix := "test:" + t.Name()
+	t.Cleanup(func() { client.Del(context.Background(), prefix+":policies") })
+
+	m, err := guard.NewPolicyManagerRedis(client, prefix)
+	assert.Nil(t, err, "should be successful")
+
+	testPolicyManager(t, m)
+}
+
+func TestPolicyManagerPostgres(t *testing.T) {
+	dsn := os.Getenv("TOOLKIT_TEST_POSTGRES_URL")
+	if dsn == "" {
+		t.Skip("TOOLKIT_TEST_POSTGRES_URL is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := db.InitPGXPoolFromDSN(dsn)
+	assert.Nil(t, err, "should be successful")
+	t.Cleanup(pool.Close)
+
+	err = guard.MigratePolicies(ctx, pool)
+	assert.Nil(t, err, "should be successful")
+
+	_, err = pool.Exec(ctx, `TRUNCATE ladon_policies`)
+	assert.Nil(t, err, "should be successful")
+
+	m, err := guard.NewPolicyManagerPostgres(pool)
+	assert.Nil(t, err, "should be successful")
+
+	testPolicyManager(t, m)
+}
+
+func TestLadonRedisInvalidation(t *testing.T) {
+	client := testRedis(t)
+	prefix := "test:" + t.Name()
+	t.Cleanup(func() { client.Del(context.Background(), prefix+":policies") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := guard.PolicyCacheOptions{Channel: prefix}
+	replicaA, err := guard.NewLadonRedis(ctx, client, prefix, opts)
+	assert.Nil(t, err, "should be successful")
+
+	replicaB, err := guard.NewLadonRedis(ctx, client, prefix, opts)
+	assert.Nil(t, err, "should be successful")
+
+	r := &ladon.Request{Subject: "foo", Resource: "bar", Action: "qux"}
+	assert.ErrorIs(t, replicaB.IsAllowed(ctx, r), ladon.ErrRequestDenied)
+
+	err = replicaA.Manager.Create(ctx, &ladon.DefaultPolicy{ID: "foo", Subjects: []string{"foo"}, Resources: []string{"bar"}, Actions: []string{"qux"}, Effect: ladon.AllowAccess})
+	assert.Nil(t, err, "should be successful")
+
+	assert.Eventually(t, func() bool {
+		return replicaB.IsAllowed(ctx, r) == nil
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS guard_decisions (
+	id         BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ NOT NULL,
+	subject    TEXT NOT NULL,
+	resource   TEXT NOT NULL,
+	action     TEXT NOT NULL,
+	context    JSONB,
+	allowed    BOOLEAN NOT NULL,
+	reason     TEXT NOT NULL,
+	policy_id  TEXT NOT NULL,
+	condition  TEXT NOT NULL,
+	error      TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS guard_decisions_subject_idx ON guard_decisions (subject, created_at);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS ladon_policies (
+	id         TEXT PRIMARY KEY,
+	document   JSONB NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS rbac_roles (
+	name     TEXT PRIMARY KEY,
+	inherits TEXT[] NOT NULL DEFAULT '{}'
+);
+
+CREATE TABLE IF NOT EXISTS rbac_permissions (
+	role     TEXT NOT NULL REFERENCES rbac_roles (name) ON DELETE CASCADE,
+	resource TEXT NOT NULL,
+	action   TEXT NOT NULL,
+	PRIMARY KEY (role, resource, action)
+);
+
+CREATE TABLE IF NOT EXISTS rbac_assignments (
+	subject TEXT NOT NULL,
+	role    TEXT NOT NULL REFERENCES rbac_roles (name) ON DELETE CASCADE,
+	PRIMARY KEY (subject, role)
+);
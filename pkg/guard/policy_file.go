@@ -0,0 +1,186 @@
+package guard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ory/ladon"
+	"github.com/ory/ladon/compiler"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// policyFile is either a list of policies or an object with a policies key.
+type policyFile struct {
+	Policies []json.RawMessage `json:"policies"`
+}
+
+func isPolicyFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+
+	return false
+}
+
+// policyFiles expands directories (recursively) into their .json, .yaml and .yml files.
+func policyFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() && isPolicyFile(p) {
+				files = append(files, p)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// LoadPolicies reads ladon policies from YAML or JSON files and directories.
+// Every policy is validated and all problems are returned at once, wrapping ErrInvalidPolicy.
+func LoadPolicies(paths ...string) (ladon.Policies, error) {
+	files, err := policyFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var policies ladon.Policies
+	var errs []error
+	seen := map[string]string{}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		documents, err := parsePolicyFile(file, b)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrInvalidPolicy, file, err))
+			continue
+		}
+
+		for i, document := range documents {
+			var policy ladon.DefaultPolicy
+			if err := json.Unmarshal(document, &policy); err != nil {
+				errs = append(errs, fmt.Errorf("%w: %s: policy %d: %w", ErrInvalidPolicy, file, i, err))
+				continue
+			}
+
+			if err := ValidatePolicy(&policy); err != nil {
+				errs = append(errs, fmt.Errorf("%s: policy %d: %w", file, i, err))
+				continue
+			}
+
+			if other, ok := seen[policy.ID]; ok {
+				errs = append(errs, fmt.Errorf("%w: %s: duplicate id %q, already defined in %s", ErrInvalidPolicy, file, policy.ID, other))
+				continue
+			}
+
+			seen[policy.ID] = file
+			policies = append(policies, &policy)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return policies, nil
+}
+
+func parsePolicyFile(file string, b []byte) ([]json.RawMessage, error) {
+	if strings.ToLower(filepath.Ext(file)) != ".json" {
+		// ladon only unmarshals conditions from JSON
+		var v any
+		if err := yaml.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+
+		var err error
+		b, err = json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var documents []json.RawMessage
+	if err := json.Unmarshal(b, &documents); err == nil {
+		return documents, nil
+	}
+
+	var f policyFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+
+	return f.Policies, nil
+}
+
+// ValidatePolicy checks what ladon would otherwise only report while evaluating a request.
+func ValidatePolicy(policy ladon.Policy) error {
+	if policy.GetID() == "" {
+		return fmt.Errorf("%w: missing id", ErrInvalidPolicy)
+	}
+
+	if policy.GetEffect() != ladon.AllowAccess && policy.GetEffect() != ladon.DenyAccess {
+		return fmt.Errorf("%w: %s: invalid effect %q", ErrInvalidPolicy, policy.GetID(), policy.GetEffect())
+	}
+
+	fields := []struct {
+		name   string
+		values []string
+	}{
+		{"subjects", policy.GetSubjects()},
+		{"resources", policy.GetResources()},
+		{"actions", policy.GetActions()},
+	}
+
+	for _, field := range fields {
+		if len(field.values) == 0 {
+			return fmt.Errorf("%w: %s: missing %s", ErrInvalidPolicy, policy.GetID(), field.name)
+		}
+
+		for _, v := range field.values {
+			if _, err := compiler.CompileRegex(v, policy.GetStartDelimiter(), policy.GetEndDelimiter()); err != nil {
+				return fmt.Errorf("%w: %s: %s %q: %w", ErrInvalidPolicy, policy.GetID(), field.name, v, err)
+			}
+		}
+	}
+
+	for key, condition := range policy.GetConditions() {
+		if _, ok := ladon.ConditionFactories[condition.GetName()]; !ok {
+			return fmt.Errorf("%w: %s: condition %q has unknown type %q", ErrInvalidPolicy, policy.GetID(), key, condition.GetName())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package guard_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hiendaovinh/toolkit/pkg/guard"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+)
+
+const policiesYAML = `
+- id: articles-read
+  subjects: ["users:<.*>"]
+  resources: ["articles:<[0-9]+>"]
+  actions: [read]
+  effect: allow
+  conditions:
+    owner:
+      type: EqualsSubjectCondition
+`
+
+const policiesJSON = `{"policies": [
+	{"id": "articles-delete", "subjects": ["admin"], "resources": ["articles:<.*>"], "actions": ["delete"], "effect": "deny"}
+]}`
+
+func writeFile(t *testing.T, path string, content string) {
+	err := os.WriteFile(path, []byte(content), 0o600)
+	assert.Nil(t, err, "should be successful")
+}
+
+func TestLoadPolicies(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "articles.yaml"), policiesYAML)
+	writeFile(t, filepath.Join(dir, "admin.json"), policiesJSON)
+	writeFile(t, filepath.Join(dir, "README.md"), "ignored")
+
+	policies, err := guard.LoadPolicies(dir)
+	assert.Nil(t, err, "should be successful")
+	assert.Len(t, policies, 2)
+
+	warden, err := guard.NewLadon(policies)
+	assert.Nil(t, err, "should be successful")
+
+	err = warden.IsAllowed(context.Background(), &ladon.Request{Subject: "users:1", Resource: "articles:1", Action: "read", Context: ladon.Context{"owner": "users:1"}})
+	assert.Nil(t, err, "should be successful")
+
+	err = warden.IsAllowed(context.Background(), &ladon.Request{Subject: "users:1", Resource: "articles:1", Action: "read", Context: ladon.Context{"owner": "users:2"}})
+	assert.ErrorIs(t, err, ladon.ErrRequestDenied)
+}
+
+func TestLoadPoliciesInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown condition": `[{"id": "a", "subjects": ["a"], "resources": ["a"], "actions": ["a"], "effect": "allow", "conditions": {"x": {"type": "NopeCondition"}}}]`,
+		"malformed regex":   `[{"id": "a", "subjects": ["a:<[>"], "resources": ["a"], "actions": ["a"], "effect": "allow"}]`,
+		"unbalanced":        `[{"id": "a", "subjects": ["a:<.*"], "resources": ["a"], "actions": ["a"], "effect": "allow"}]`,
+		"invalid effect":    `[{"id": "a", "subjects": ["a"], "resources": ["a"], "actions": ["a"], "effect": "maybe"}]`,
+		"duplicate id": `[
+			{"id": "a", "subjects": ["a"], "resources": ["a"], "actions": ["a"], "effect": "allow"},
+			{"id": "a", "subjects": ["b"], "resources": ["b"], "actions": ["b"], "effect": "allow"}
+		]`,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policies.json")
+			writeFile(t, path, content)
+
+			_, err := guard.LoadPolicies(path)
+			assert.ErrorIs(t, err, guard.ErrInvalidPolicy)
+		})
+	}
+}
+
+func TestPolicyWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policies.yaml")
+	writeFile(t, path, policiesYAML)
+
+	var mu sync.Mutex
+	var errs []error
+	w, err := guard.NewPolicyWatcher(ctx, guard.PolicyWatcherOptions{
+		Debounce: 10 * time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	}, path)
+	assert.Nil(t, err, "should be successful")
+
+	r := &ladon.Request{Subject: "admin", Resource: "articles:1", Action: "delete"}
+	assert.ErrorIs(t, w.IsAllowed(ctx, r), ladon.ErrRequestDenied)
+
+	writeFile(t, path, `[{"id": "a", "subjects": ["admin"], "resources": ["articles:<.*>"], "actions": ["delete"], "effect": "allow"}]`)
+	assert.Eventually(t, func() bool {
+		return w.IsAllowed(ctx, r) == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// a broken file keeps the previous policies
+	writeFile(t, path, `[{"id": "a", "subjects": ["admin"], "effect": "allow"}]`)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) > 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, w.IsAllowed(ctx, r))
+}
+
+func TestPolicyWatcherNewDirectory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "policies.yaml"), policiesYAML)
+
+	w, err := guard.NewPolicyWatcher(ctx, guard.PolicyWatcherOptions{Debounce: 10 * time.Millisecond}, dir)
+	assert.Nil(t, err, "should be successful")
+
+	r := &ladon.Request{Subject: "admin", Resource: "articles:1", Action: "delete"}
+	assert.ErrorIs(t, w.IsAllowed(ctx, r), ladon.ErrRequestDenied)
+
+	sub := filepath.Join(dir, "sub")
+	assert.Nil(t, os.Mkdir(sub, 0o755))
+	writeFile(t, filepath.Join(sub, "delete.json"), `[{"id": "delete", "subjects": ["admin"], "resources": ["articles:<.*>"], "actions": ["delete"], "effect": "allow"}]`)
+	assert.Eventually(t, func() bool {
+		return w.IsAllowed(ctx, r) == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// later changes in the new directory are followed too
+	writeFile(t, filepath.Join(sub, "delete.json"), `[]`)
+	assert.Eventually(t, func() bool {
+		return w.IsAllowed(ctx, r) != nil
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,184 @@
+package guard
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ory/ladon"
+)
+
+type PolicyWatcherOptions struct {
+	// Debounce groups the events of a single save, defaults to 100ms.
+	Debounce time.Duration
+	// OnError receives load failures, the previous policies stay in use.
+	OnError func(error)
+	// OnReload is called after a new policy set is in use.
+	OnReload func(ladon.Policies)
+}
+
+// PolicyWatcher is an AuthChecker over policy files, reloaded when they change.
+type PolicyWatcher struct {
+	paths   []string
+	opts    PolicyWatcherOptions
+	current atomic.Pointer[ladon.Ladon]
+}
+
+// NewPolicyWatcher loads the policies of paths, see LoadPolicies, and watches them until ctx is done.
+// Directories are watched recursively, including subdirectories created later.
+// The initial load has to succeed.
+func NewPolicyWatcher(ctx context.Context, opts PolicyWatcherOptions, paths ...string) (*PolicyWatcher, error) {
+	if opts.Debounce <= 0 {
+		opts.Debounce = 100 * time.Millisecond
+	}
+
+	w := &PolicyWatcher{paths: paths, opts: opts}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// editors usually replace files, watch their directories instead
+	var roots []string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			err = watcher.Add(filepath.Dir(path))
+			if err != nil {
+				watcher.Close()
+				return nil, err
+			}
+
+			continue
+		}
+
+		roots = append(roots, path)
+		if err := watchTree(watcher, path); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	go w.watch(ctx, watcher, roots)
+	return w, nil
+}
+
+// watch follows the files of paths, directories created later under roots are watched as well.
+func (w *PolicyWatcher) watch(ctx context.Context, watcher *fsnotify.Watcher, roots []string) {
+	defer watcher.Close()
+
+	timer := time.NewTimer(0)
+	<-timer.C
+
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			// files may land in a new directory before it is watched, reload anyway
+			if event.Has(fsnotify.Create) && isWithin(roots, event.Name) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watchTree(watcher, event.Name); err != nil {
+						w.fail(err)
+					}
+
+					timer.Reset(w.opts.Debounce)
+					continue
+				}
+			}
+
+			if event.Has(fsnotify.Chmod) || !isPolicyFile(event.Name) {
+				continue
+			}
+
+			timer.Reset(w.opts.Debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+
+			w.fail(err)
+		case <-timer.C:
+			if err := w.Reload(); err != nil {
+				w.fail(err)
+			}
+		}
+	}
+}
+
+func watchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		return watcher.Add(path)
+	})
+}
+
+func isWithin(roots []string, path string) bool {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *PolicyWatcher) fail(err error) {
+	if w.opts.OnError != nil {
+		w.opts.OnError(err)
+	}
+}
+
+// Reload loads the files again and swaps the policies in use, keeping the previous ones on error.
+func (w *PolicyWatcher) Reload() error {
+	policies, err := LoadPolicies(w.paths...)
+	if err != nil {
+		return err
+	}
+
+	warden, err := NewLadon(policies)
+	if err != nil {
+		return err
+	}
+
+	w.current.Store(warden)
+	if w.opts.OnReload != nil {
+		w.opts.OnReload(policies)
+	}
+
+	return nil
+}
+
+// Ladon returns the policies currently in use.
+func (w *PolicyWatcher) Ladon() *ladon.Ladon {
+	return w.current.Load()
+}
+
+func (w *PolicyWatcher) IsAllowed(ctx context.Context, r *ladon.Request) error {
+	return w.current.Load().IsAllowed(ctx, r)
+}
+
+func (w *PolicyWatcher) Explain(ctx context.Context, r *ladon.Request) (Decision, error) {
+	return ExplainLadon(ctx, w.current.Load(), r)
+}
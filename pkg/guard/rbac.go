@@ -0,0 +1,304 @@
+package guard
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hiendaovinh/toolkit/pkg/auth"
+	"github.com/ory/ladon"
+)
+
+var ErrRoleNotFound = errors.New("role not found")
+
+// Permission patterns use the ladon syntax, e.g. articles:<.*>.
+type Permission struct {
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
+}
+
+// Role grants its permissions and the ones of the roles it inherits.
+type Role struct {
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+	Inherits    []string     `json:"inherits"`
+}
+
+type RoleStore interface {
+	SaveRole(ctx context.Context, role Role) error
+	DeleteRole(ctx context.Context, name string) error
+	Roles(ctx context.Context) ([]Role, error)
+	// RolesByName returns the named roles, unknown names are skipped.
+	RolesByName(ctx context.Context, names []string) ([]Role, error)
+	Assign(ctx context.Context, subject string, role string) error
+	Unassign(ctx context.Context, subject string, role string) error
+	SubjectRoles(ctx context.Context, subject string) ([]string, error)
+}
+
+type RBACOptions struct {
+	// ClaimsKey is the metadata key of the JWT claims holding extra roles of the subject, defaults to roles.
+	// Either a list of strings or a comma separated string.
+	ClaimsKey string
+}
+
+// RBAC is an AuthChecker granting the permissions of the roles of the subject.
+// It never denies explicitly, combine it with ladon policies for that.
+type RBAC struct {
+	store     RoleStore
+	claimsKey string
+	matcher   policyMatcher
+}
+
+func NewRBAC(store RoleStore, opts RBACOptions) (*RBAC, error) {
+	if store == nil {
+		return nil, errors.New("invalid role store")
+	}
+
+	if opts.ClaimsKey == "" {
+		opts.ClaimsKey = "roles"
+	}
+
+	return &RBAC{store: store, claimsKey: opts.ClaimsKey, matcher: ladon.DefaultMatcher}, nil
+}
+
+// RolesFromClaims reads the roles stored at key in the claims metadata.
+func RolesFromClaims(metadata map[string]any, key string) []string {
+	switch v := metadata[key].(type) {
+	case string:
+		var roles []string
+		for _, role := range strings.Split(v, ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				roles = append(roles, role)
+			}
+		}
+
+		return roles
+	case []string:
+		return v
+	case []any:
+		roles := make([]string, 0, len(v))
+		for _, role := range v {
+			if s, ok := role.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+
+		return roles
+	}
+
+	return nil
+}
+
+// SubjectRoles returns the assigned roles of the subject, plus the ones of its claims when ctx was authenticated as the subject.
+func (rbac *RBAC) SubjectRoles(ctx context.Context, subject string) ([]string, error) {
+	roles, err := rbac.store.SubjectRoles(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := auth.ResolveClaims(ctx); ok && claims.Subject == subject {
+		roles = append(roles, RolesFromClaims(claims.Metadata, rbac.claimsKey)...)
+	}
+
+	slices.Sort(roles)
+	return slices.Compact(roles), nil
+}
+
+func (rbac *RBAC) IsAllowed(ctx context.Context, r *ladon.Request) error {
+	decision, err := rbac.Explain(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return decision.Err()
+}
+
+// Explain reports the granting role as the PolicyID, prefixed with role:.
+func (rbac *RBAC) Explain(ctx context.Context, r *ladon.Request) (Decision, error) {
+	roles, err := rbac.subjectRoles(ctx, r.Subject)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	return rbac.explain(roles, r)
+}
+
+// subjectRoles returns the roles of the subject and the ones they inherit, closest first.
+func (rbac *RBAC) subjectRoles(ctx context.Context, subject string) ([]Role, error) {
+	names, err := rbac.SubjectRoles(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	// the roles are loaded one level of inheritance at a time
+	var resolved []Role
+	visited := map[string]bool{}
+	for len(names) > 0 {
+		var level []string
+		for _, name := range names {
+			if !visited[name] {
+				visited[name] = true
+				level = append(level, name)
+			}
+		}
+
+		if len(level) == 0 {
+			break
+		}
+
+		loaded, err := rbac.store.RolesByName(ctx, level)
+		if err != nil {
+			return nil, err
+		}
+
+		roles := make(map[string]Role, len(loaded))
+		for _, role := range loaded {
+			roles[role.Name] = role
+		}
+
+		names = nil
+		for _, name := range level {
+			role, ok := roles[name]
+			if !ok {
+				continue
+			}
+
+			resolved = append(resolved, role)
+			names = append(names, role.Inherits...)
+		}
+	}
+
+	return resolved, nil
+}
+
+func (rbac *RBAC) explain(roles []Role, r *ladon.Request) (Decision, error) {
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			ok, err := rbac.grants(permission, r.Resource, r.Action)
+			if err != nil {
+				return Decision{}, err
+			}
+
+			if ok {
+				return Decision{Allowed: true, Reason: ReasonAllowed, PolicyID: "role:" + role.Name}, nil
+			}
+		}
+	}
+
+	return Decision{Reason: ReasonNoMatch}, nil
+}
+
+// the policy only carries the delimiters for the matcher
+var rbacPolicy = &ladon.DefaultPolicy{}
+
+func (rbac *RBAC) grants(permission Permission, resource string, action string) (bool, error) {
+	ok, err := rbac.matcher.Matches(rbacPolicy, []string{permission.Action}, action)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return rbac.matcher.Matches(rbacPolicy, []string{permission.Resource}, resource)
+}
+
+type RoleStoreMemory struct {
+	mu          sync.RWMutex
+	roles       map[string]Role
+	assignments map[string]map[string]bool
+}
+
+func NewRoleStoreMemory(roles ...Role) *RoleStoreMemory {
+	store := &RoleStoreMemory{roles: map[string]Role{}, assignments: map[string]map[string]bool{}}
+	for _, role := range roles {
+		store.roles[role.Name] = role
+	}
+
+	return store
+}
+
+func (s *RoleStoreMemory) SaveRole(ctx context.Context, role Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.roles[role.Name] = role
+	return nil
+}
+
+func (s *RoleStoreMemory) DeleteRole(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.roles, name)
+	for _, roles := range s.assignments {
+		delete(roles, name)
+	}
+
+	return nil
+}
+
+func (s *RoleStoreMemory) Roles(ctx context.Context) ([]Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roles := make([]Role, 0, len(s.roles))
+	for _, role := range s.roles {
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+func (s *RoleStoreMemory) RolesByName(ctx context.Context, names []string) ([]Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roles := make([]Role, 0, len(names))
+	for _, name := range names {
+		if role, ok := s.roles[name]; ok {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles, nil
+}
+
+func (s *RoleStoreMemory) Assign(ctx context.Context, subject string, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.roles[role]; !ok {
+		return ErrRoleNotFound
+	}
+
+	if s.assignments[subject] == nil {
+		s.assignments[subject] = map[string]bool{}
+	}
+
+	s.assignments[subject][role] = true
+	return nil
+}
+
+func (s *RoleStoreMemory) Unassign(ctx context.Context, subject string, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.assignments[subject], role)
+	return nil
+}
+
+func (s *RoleStoreMemory) SubjectRoles(ctx context.Context, subject string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roles := make([]string, 0, len(s.assignments[subject]))
+	for role := range s.assignments[subject] {
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
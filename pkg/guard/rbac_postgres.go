@@ -0,0 +1,141 @@
+package guard
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/rbac.sql
+var migrationRBAC string
+
+// MigrateRBAC creates the rbac_roles, rbac_permissions and rbac_assignments tables used by RoleStorePostgres.
+func MigrateRBAC(ctx context.Context, pool *pgxpool.Pool) error {
+	_, err := pool.Exec(ctx, migrationRBAC)
+	return err
+}
+
+type RoleStorePostgres struct {
+	pool *pgxpool.Pool
+}
+
+func NewRoleStorePostgres(pool *pgxpool.Pool) (*RoleStorePostgres, error) {
+	if pool == nil {
+		return nil, errors.New("invalid postgres pool")
+	}
+
+	return &RoleStorePostgres{pool}, nil
+}
+
+// SaveRole creates or replaces the role and its permissions.
+func (s *RoleStorePostgres) SaveRole(ctx context.Context, role Role) error {
+	inherits := role.Inherits
+	if inherits == nil {
+		inherits = []string{}
+	}
+
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `INSERT INTO rbac_roles (name, inherits) VALUES ($1, $2) ON CONFLICT (name) DO UPDATE SET inherits = excluded.inherits`, role.Name, inherits)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM rbac_permissions WHERE role = $1`, role.Name)
+		if err != nil {
+			return err
+		}
+
+		for _, permission := range role.Permissions {
+			_, err = tx.Exec(ctx, `INSERT INTO rbac_permissions (role, resource, action) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, role.Name, permission.Resource, permission.Action)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *RoleStorePostgres) DeleteRole(ctx context.Context, name string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM rbac_roles WHERE name = $1`, name)
+	return err
+}
+
+func (s *RoleStorePostgres) Roles(ctx context.Context) ([]Role, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT r.name, r.inherits, p.resource, p.action
+		FROM rbac_roles r LEFT JOIN rbac_permissions p ON p.role = r.name
+		ORDER BY r.name`)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanRoles(rows)
+}
+
+func (s *RoleStorePostgres) RolesByName(ctx context.Context, names []string) ([]Role, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT r.name, r.inherits, p.resource, p.action
+		FROM rbac_roles r LEFT JOIN rbac_permissions p ON p.role = r.name
+		WHERE r.name = ANY($1)
+		ORDER BY r.name`, names)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanRoles(rows)
+}
+
+// scanRoles groups the permission rows of roles ordered by name.
+func scanRoles(rows pgx.Rows) ([]Role, error) {
+	defer rows.Close()
+
+	var roles []Role
+	for rows.Next() {
+		var name string
+		var inherits []string
+		var resource, action *string
+		if err := rows.Scan(&name, &inherits, &resource, &action); err != nil {
+			return nil, err
+		}
+
+		if len(roles) == 0 || roles[len(roles)-1].Name != name {
+			roles = append(roles, Role{Name: name, Inherits: inherits})
+		}
+
+		if resource != nil && action != nil {
+			role := &roles[len(roles)-1]
+			role.Permissions = append(role.Permissions, Permission{Resource: *resource, Action: *action})
+		}
+	}
+
+	return roles, rows.Err()
+}
+
+func (s *RoleStorePostgres) Assign(ctx context.Context, subject string, role string) error {
+	_, err := s.pool.Exec(ctx, `INSERT INTO rbac_assignments (subject, role) VALUES ($1, $2) ON CONFLICT DO NOTHING`, subject, role)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrRoleNotFound
+	}
+
+	return err
+}
+
+func (s *RoleStorePostgres) Unassign(ctx context.Context, subject string, role string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM rbac_assignments WHERE subject = $1 AND role = $2`, subject, role)
+	return err
+}
+
+func (s *RoleStorePostgres) SubjectRoles(ctx context.Context, subject string) ([]string, error) {
+	rows, err := s.pool.Query(ctx, `SELECT role FROM rbac_assignments WHERE subject = $1`, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
@@ -0,0 +1,142 @@
+package guard_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hiendaovinh/toolkit/pkg/auth"
+	"github.com/hiendaovinh/toolkit/pkg/db"
+	"github.com/hiendaovinh/toolkit/pkg/guard"
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRBAC(t *testing.T, store guard.RoleStore) {
+	ctx := context.Background()
+
+	roles := []guard.Role{
+		{Name: "reader", Permissions: []guard.Permission{{Resource: "articles:<.*>", Action: "read"}}},
+		{Name: "editor", Permissions: []guard.Permission{{Resource: "articles:<.*>", Action: "<update|create>"}}, Inherits: []string{"reader"}},
+		{Name: "admin", Permissions: []guard.Permission{{Resource: "<.*>", Action: "<.*>"}}, Inherits: []string{"editor", "admin"}},
+	}
+
+	for _, role := range roles {
+		err := store.SaveRole(ctx, role)
+		assert.Nil(t, err, "should be successful")
+	}
+
+	err := store.Assign(ctx, "alice", "editor")
+	assert.Nil(t, err, "should be successful")
+
+	err = store.Assign(ctx, "alice", "missing")
+	assert.ErrorIs(t, err, guard.ErrRoleNotFound)
+
+	rbac, err := guard.NewRBAC(store, guard.RBACOptions{})
+	assert.Nil(t, err, "should be successful")
+
+	test := beforeEach(t)
+	g, err := guard.NewGuard(test.authn, rbac)
+	assert.Nil(t, err, "should be successful")
+
+	// inherited from reader
+	decision, err := g.Decide(ctx, "alice", "articles:1", "read", nil)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, guard.Decision{Allowed: true, Reason: guard.ReasonAllowed, PolicyID: "role:reader"}, decision)
+
+	assert.Nil(t, g.AllowContext(ctx, "alice", "articles:1", "update", nil))
+	assert.ErrorIs(t, g.AllowContext(ctx, "alice", "articles:1", "delete", nil), ladon.ErrRequestDenied)
+	assert.ErrorIs(t, g.AllowContext(ctx, "bob", "articles:1", "read", nil), ladon.ErrRequestDenied)
+
+	// roles of the claims only apply to the authenticated subject
+	ctxBob := auth.WithAuthClaims(ctx, &jwtx.JWTClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "bob"},
+		Metadata:         map[string]any{"roles": []any{"admin"}},
+	})
+	assert.Nil(t, g.AllowContext(ctxBob, "bob", "users:1", "delete", nil))
+	assert.ErrorIs(t, g.AllowContext(ctxBob, "alice", "users:1", "delete", nil), ladon.ErrRequestDenied)
+
+	err = store.Unassign(ctx, "alice", "editor")
+	assert.Nil(t, err, "should be successful")
+	assert.ErrorIs(t, g.AllowContext(ctx, "alice", "articles:1", "read", nil), ladon.ErrRequestDenied)
+
+	err = store.Assign(ctx, "alice", "reader")
+	assert.Nil(t, err, "should be successful")
+
+	err = store.DeleteRole(ctx, "reader")
+	assert.Nil(t, err, "should be successful")
+
+	subjectRoles, err := store.SubjectRoles(ctx, "alice")
+	assert.Nil(t, err, "should be successful")
+	assert.Empty(t, subjectRoles)
+}
+
+func TestRBACMemory(t *testing.T) {
+	testRBAC(t, guard.NewRoleStoreMemory())
+}
+
+// roleStoreRecorder records the roles RBAC loads.
+type roleStoreRecorder struct {
+	*guard.RoleStoreMemory
+	loaded []string
+}
+
+func (s *roleStoreRecorder) Roles(ctx context.Context) ([]guard.Role, error) {
+	panic("RBAC should not load every role")
+}
+
+func (s *roleStoreRecorder) RolesByName(ctx context.Context, names []string) ([]guard.Role, error) {
+	s.loaded = append(s.loaded, names...)
+	return s.RoleStoreMemory.RolesByName(ctx, names)
+}
+
+func TestRBACLoadsSubjectRoles(t *testing.T) {
+	ctx := context.Background()
+	store := &roleStoreRecorder{RoleStoreMemory: guard.NewRoleStoreMemory(
+		guard.Role{Name: "reader", Permissions: []guard.Permission{{Resource: "articles:<.*>", Action: "read"}}},
+		guard.Role{Name: "editor", Inherits: []string{"reader", "editor"}},
+		guard.Role{Name: "billing", Permissions: []guard.Permission{{Resource: "invoices:<.*>", Action: "read"}}},
+	)}
+
+	err := store.Assign(ctx, "alice", "editor")
+	assert.Nil(t, err, "should be successful")
+
+	rbac, err := guard.NewRBAC(store, guard.RBACOptions{})
+	assert.Nil(t, err, "should be successful")
+
+	decision, err := rbac.Explain(ctx, &ladon.Request{Subject: "alice", Resource: "articles:1", Action: "read"})
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, "role:reader", decision.PolicyID)
+	assert.Equal(t, []string{"editor", "reader"}, store.loaded)
+}
+
+func TestRBACPostgres(t *testing.T) {
+	dsn := os.Getenv("TOOLKIT_TEST_POSTGRES_URL")
+	if dsn == "" {
+		t.Skip("TOOLKIT_TEST_POSTGRES_URL is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := db.InitPGXPoolFromDSN(dsn)
+	assert.Nil(t, err, "should be successful")
+	t.Cleanup(pool.Close)
+
+	err = guard.MigrateRBAC(ctx, pool)
+	assert.Nil(t, err, "should be successful")
+
+	_, err = pool.Exec(ctx, `TRUNCATE rbac_roles CASCADE`)
+	assert.Nil(t, err, "should be successful")
+
+	store, err := guard.NewRoleStorePostgres(pool)
+	assert.Nil(t, err, "should be successful")
+
+	testRBAC(t, store)
+}
+
+func TestRolesFromClaims(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, guard.RolesFromClaims(map[string]any{"roles": "a, b"}, "roles"))
+	assert.Equal(t, []string{"a"}, guard.RolesFromClaims(map[string]any{"roles": []any{"a", 1}}, "roles"))
+	assert.Nil(t, guard.RolesFromClaims(nil, "roles"))
+}
@@ -0,0 +1,43 @@
+package httpx
+
+import (
+	"github.com/hiendaovinh/toolkit/pkg/auth"
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+	"github.com/labstack/echo/v4"
+)
+
+// ContextBuilder returns the ladon request context, e.g. the owner of the resource.
+type ContextBuilder func(c echo.Context) (map[string]any, error)
+
+// Authz requires an authenticated subject allowed to act on the resource, see Authn.
+// Placeholders of the resource template like articles:{id} are filled from the route params,
+// the action defaults to auth.ActionFromMethod and build may be nil.
+func Authz(guard auth.Authorizer, resource string, action string, build ContextBuilder) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r, err := auth.ExpandResource(resource, c.Param)
+			if err != nil {
+				return Abort(c, errorx.Wrap(err, errorx.Service))
+			}
+
+			a := action
+			if a == "" {
+				a = auth.ActionFromMethod(c.Request().Method)
+			}
+
+			var ctx map[string]any
+			if build != nil {
+				ctx, err = build(c)
+				if err != nil {
+					return RestAbort(c, nil, err)
+				}
+			}
+
+			if err := auth.Authorize(c.Request().Context(), guard, r, a, ctx); err != nil {
+				return Abort(c, err)
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -3,136 +3,80 @@ package httpx
 import (
 	"bytes"
 	"context"
-	"encoding/json"
-	"errors"
 	"io"
-	"net/http"
-	"net/url"
-	"strings"
-	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/hiendaovinh/toolkit/pkg/auth"
+	"github.com/hiendaovinh/toolkit/pkg/captcha"
 	"github.com/hiendaovinh/toolkit/pkg/errorx"
+	std "github.com/hiendaovinh/toolkit/pkg/httpx-std"
 	"github.com/hiendaovinh/toolkit/pkg/jwtx"
 	"github.com/hiendaovinh/toolkit/pkg/limiter"
 	"github.com/labstack/echo/v4"
+	"github.com/unrolled/secure"
 )
 
-type Guard interface {
-	AuthenticateJWT(ctx context.Context, tokenStr string) (*jwt.Token, *jwtx.JWTClaims, error)
-}
+type Guard = std.Guard
+
+type GuardWithOptions = std.GuardWithOptions
 
 func Authn(guard Guard) echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			header := c.Request().Header.Get("Authorization")
-			if header == "" {
-				return next(c)
-			}
+	return AuthnWithOptions(guard, auth.AuthnOptions{})
+}
 
-			parts := strings.Split(header, "Bearer")
-			if len(parts) != 2 {
-				return next(c)
-			}
+// AuthnWithOptions reads the token with opts.Extractor, see auth.FirstOf to accept cookies or query parameters.
+func AuthnWithOptions(guard Guard, opts auth.AuthnOptions) echo.MiddlewareFunc {
+	return authn(guard.AuthenticateJWT, opts)
+}
 
-			token := strings.TrimSpace(parts[1])
-			if len(token) == 0 {
-				return next(c)
-			}
+// AuthnWithValidation authenticates with its own claim validation, e.g. a route only accepting a given audience.
+func AuthnWithValidation(guard GuardWithOptions, validation jwtx.ValidationOptions) echo.MiddlewareFunc {
+	return AuthnWithValidationOptions(guard, validation, auth.AuthnOptions{})
+}
+
+// AuthnWithValidationOptions is AuthnWithValidation reading the token with opts.Extractor.
+func AuthnWithValidationOptions(guard GuardWithOptions, validation jwtx.ValidationOptions, opts auth.AuthnOptions) echo.MiddlewareFunc {
+	return authn(std.AuthenticatorWithValidation(guard, validation), opts)
+}
 
-			_, claims, err := guard.AuthenticateJWT(c.Request().Context(), token)
+func authn(authenticate auth.Authenticator, opts auth.AuthnOptions) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx, err := auth.Authenticate(c.Request(), authenticate, opts)
 			if err != nil {
-				// although it's a client error, we don't want to detailed information
-				//nolint:errcheck
-				Abort(c, errorx.Wrap(errors.New("invalid access token"), errorx.Authn), -1)
-				return nil
+				return Abort(c, err)
 			}
 
-			ctx := c.Request().Context()
-			ctx = auth.WithAuthJWT(ctx, token)
-			ctx = auth.WithAuthClaims(ctx, claims)
 			c.SetRequest(c.Request().WithContext(ctx))
 			return next(c)
 		}
 	}
 }
 
-type CaptchaVerifyResponse struct {
-	Success     bool      `json:"success"`
-	Score       float64   `json:"score"`
-	Action      string    `json:"action"`
-	ChallengeTS time.Time `json:"challenge_ts"`
-	Hostname    string    `json:"hostname"`
-	ErrorCodes  []string  `json:"error-codes"`
-}
+type CaptchaVerifyResponse = std.CaptchaVerifyResponse
 
-type CaptchaRequirement struct {
-	Secret         string
-	Score          float64
-	Action         string
-	FallbackSecret string
-	Bypass         string
-}
+type CaptchaRequirement = std.CaptchaRequirement
 
-type CaptchaPayload struct {
-	Captcha         string `json:"captcha"`
-	CaptchaFallback string `json:"captcha_fallback"`
-}
+type CaptchaPayload = std.CaptchaPayload
 
+// CaptchaValid verifies the captcha of the JSON body, see std.CaptchaRequirement.
+// Unlike std and gin, Action stays optional for the reCAPTCHA v3 verifier built from Secret, as it always was here.
 func CaptchaValid(requirement CaptchaRequirement) echo.MiddlewareFunc {
-	recaptchaV3 := func(next echo.HandlerFunc, c echo.Context, payload CaptchaPayload) error {
-		resp, err := http.PostForm("https://www.google.com/recaptcha/api/siteverify", url.Values{
-			"secret":   {requirement.Secret},
-			"response": {payload.Captcha},
-		})
-		if err != nil {
-			return Abort(c, errorx.Wrap(err, errorx.Service))
-		}
-		defer resp.Body.Close()
-
-		var body CaptchaVerifyResponse
-		if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
-			return Abort(c, errorx.Wrap(err, errorx.Service))
-		}
-
-		if requirement.Action != "" && body.Action != requirement.Action {
-			return Abort(c, errorx.Wrap(errors.New("captcha failed"), errorx.Authz))
-		}
-
-		if !body.Success || body.Score < requirement.Score {
-			return Abort(c, errorx.Wrap(errors.New("captcha failed"), errorx.Authz))
+	if requirement.Verifier == nil && requirement.Secret != "" && requirement.Action == "" {
+		if v, err := captcha.NewRecaptchaV3(captcha.Options{Secret: requirement.Secret, Score: requirement.Score}); err == nil {
+			requirement.Verifier = v
 		}
-
-		return next(c)
 	}
 
-	recaptchaV2 := func(next echo.HandlerFunc, c echo.Context, payload CaptchaPayload) error {
-		resp, err := http.PostForm("https://www.google.com/recaptcha/api/siteverify", url.Values{
-			"secret":   {requirement.FallbackSecret},
-			"response": {payload.CaptchaFallback},
-		})
-		if err != nil {
-			return Abort(c, errorx.Wrap(err, errorx.Service))
-		}
-		defer resp.Body.Close()
-
-		var body CaptchaVerifyResponse
-		if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
-			return Abort(c, errorx.Wrap(err, errorx.Service))
-		}
-
-		if !body.Success {
-			return Abort(c, errorx.Wrap(errors.New("fallback captcha failed"), errorx.Captcha))
-		}
-
-		return next(c)
-	}
+	verifier, fallback, invalid := requirement.Verifiers()
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			if requirement.Secret == "" {
-				return Abort(c, errorx.Wrap(errors.New("missing captcha secret"), errorx.Service))
+			if invalid != nil {
+				return Abort(c, invalid)
+			}
+
+			if std.CaptchaBypassed(c.Request(), requirement) {
+				return next(c)
 			}
 
 			var buf bytes.Buffer
@@ -147,11 +91,11 @@ func CaptchaValid(requirement CaptchaRequirement) echo.MiddlewareFunc {
 
 			c.Request().Body = io.NopCloser(&buf)
 
-			if payload.CaptchaFallback != "" {
-				return recaptchaV2(next, c, payload)
+			if err := std.VerifyCaptcha(c.Request().Context(), verifier, fallback, payload); err != nil {
+				return Abort(c, err)
 			}
 
-			return recaptchaV3(next, c, payload)
+			return next(c)
 		}
 	}
 }
@@ -166,3 +110,27 @@ func DisableLimiter() echo.MiddlewareFunc {
 		}
 	}
 }
+
+func CSP(secureMiddleware *secure.Secure) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			nonce, err := secureMiddleware.ProcessAndReturnNonce(c.Response(), c.Request())
+			// If there was an error, do not continue.
+			if err != nil {
+				return nil
+			}
+
+			// Avoid header rewrite if response is a redirection.
+			if status := c.Response().Status; c.Response().Committed && status > 300 && status < 399 {
+				return nil
+			}
+
+			c.SetRequest(c.Request().WithContext(std.WithCSPNonce(c.Request().Context(), nonce)))
+			return next(c)
+		}
+	}
+}
+
+func CSPNonce(ctx context.Context) any {
+	return std.CSPNonce(ctx)
+}
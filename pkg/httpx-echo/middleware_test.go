@@ -0,0 +1,36 @@
+package httpx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httpx "github.com/hiendaovinh/toolkit/pkg/httpx-echo"
+	std "github.com/hiendaovinh/toolkit/pkg/httpx-std"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptchaValid(t *testing.T) {
+	serve := func(requirement httpx.CaptchaRequirement) int {
+		e := echo.New()
+		e.POST("/", func(c echo.Context) error {
+			return c.String(http.StatusOK, "ok")
+		}, httpx.CaptchaValid(requirement))
+
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		r.Header.Set(std.HeaderCaptchaBypass, "internal")
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	// an invalid requirement is reported before the bypass is checked, as in std and gin
+	assert.Equal(t, http.StatusInternalServerError, serve(httpx.CaptchaRequirement{Bypass: "internal"}))
+
+	// the action is optional here
+	assert.Equal(t, http.StatusOK, serve(httpx.CaptchaRequirement{Secret: "secret", Bypass: "internal"}))
+}
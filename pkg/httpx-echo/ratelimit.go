@@ -0,0 +1,27 @@
+package httpx
+
+import (
+	std "github.com/hiendaovinh/toolkit/pkg/httpx-std"
+	"github.com/labstack/echo/v4"
+)
+
+type RateLimitOptions = std.RateLimitOptions
+
+// RateLimit enforces opts.Limit, see std.CheckRateLimit. std.KeyByRoute uses the echo route path.
+func RateLimit(opts RateLimitOptions) (echo.MiddlewareFunc, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			r = r.WithContext(std.WithRoute(r.Context(), c.Path()))
+			if err := std.CheckRateLimit(c.Response().Header(), r, opts); err != nil {
+				return Abort(c, err)
+			}
+
+			return next(c)
+		}
+	}, nil
+}
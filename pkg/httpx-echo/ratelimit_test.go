@@ -0,0 +1,47 @@
+package httpx_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-redis/redis_rate/v10"
+	httpx "github.com/hiendaovinh/toolkit/pkg/httpx-echo"
+	std "github.com/hiendaovinh/toolkit/pkg/httpx-std"
+	"github.com/hiendaovinh/toolkit/pkg/limiter"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	l, err := limiter.NewLimiterMemory(ctx, limiter.LimiterMemoryOptions{})
+	assert.Nil(t, err, "should be successful")
+
+	_, err = httpx.RateLimit(httpx.RateLimitOptions{Limiter: l})
+	assert.ErrorIs(t, err, limiter.ErrInvalidLimit)
+
+	middleware, err := httpx.RateLimit(httpx.RateLimitOptions{Limiter: l, Limit: redis_rate.PerMinute(1), Key: std.KeyByRoute()})
+	assert.Nil(t, err, "should be successful")
+
+	e := echo.New()
+	handler := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/articles/:id", handler, middleware)
+	e.GET("/users/:id", handler, middleware)
+
+	serve := func(path string) int {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	// the budget is per route template, not per path
+	assert.Equal(t, http.StatusOK, serve("/articles/1"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("/articles/2"))
+	assert.Equal(t, http.StatusOK, serve("/users/1"))
+}
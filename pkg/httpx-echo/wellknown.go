@@ -0,0 +1,29 @@
+package httpx
+
+import (
+	"time"
+
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+	"github.com/labstack/echo/v4"
+)
+
+// JWKS serves the Authority keys, usually mounted at jwtx.PathJWKS.
+func JWKS(authority *jwtx.Authority, maxAge time.Duration) echo.HandlerFunc {
+	return echo.WrapHandler(jwtx.JWKSHandler(authority, maxAge))
+}
+
+// Discovery serves the OpenID configuration, usually mounted at jwtx.PathDiscovery.
+func Discovery(authority *jwtx.Authority, maxAge time.Duration) echo.HandlerFunc {
+	return echo.WrapHandler(jwtx.DiscoveryHandler(authority, maxAge))
+}
+
+// Routes is implemented by *echo.Echo and *echo.Group.
+type Routes interface {
+	GET(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+}
+
+// WellKnown mounts both documents.
+func WellKnown(e Routes, authority *jwtx.Authority, maxAge time.Duration) {
+	e.GET(jwtx.PathJWKS, JWKS(authority, maxAge))
+	e.GET(jwtx.PathDiscovery, Discovery(authority, maxAge))
+}
@@ -0,0 +1,45 @@
+package httpx
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hiendaovinh/toolkit/pkg/auth"
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+	std "github.com/hiendaovinh/toolkit/pkg/httpx-std"
+)
+
+// ContextBuilder returns the ladon request context, e.g. the owner of the resource.
+type ContextBuilder func(c *gin.Context) (map[string]any, error)
+
+// Authz requires an authenticated subject allowed to act on the resource, see Authn.
+// Placeholders of the resource template like articles:{id} are filled from the route params,
+// the action defaults to auth.ActionFromMethod and build may be nil.
+func Authz(guard auth.Authorizer, resource string, action string, build ContextBuilder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r, err := auth.ExpandResource(resource, c.Param)
+		if err != nil {
+			Abort(c, errorx.Wrap(err, errorx.Service))
+			return
+		}
+
+		a := action
+		if a == "" {
+			a = auth.ActionFromMethod(c.Request.Method)
+		}
+
+		var ctx map[string]any
+		if build != nil {
+			ctx, err = build(c)
+			if err != nil {
+				Abort(c, std.Classify(err))
+				return
+			}
+		}
+
+		if err := auth.Authorize(c.Request.Context(), guard, r, a, ctx); err != nil {
+			Abort(c, err)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -2,170 +2,95 @@ package httpx
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
-	"net/http"
-	"net/url"
-	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/hiendaovinh/toolkit/pkg/auth"
 	"github.com/hiendaovinh/toolkit/pkg/errorx"
+	std "github.com/hiendaovinh/toolkit/pkg/httpx-std"
 	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+	"github.com/hiendaovinh/toolkit/pkg/limiter"
 	"github.com/unrolled/secure"
 )
 
-type Guard interface {
-	AuthenticateJWT(ctx context.Context, tokenStr string) (*jwt.Token, *jwtx.JWTClaims, error)
-}
+type Guard = std.Guard
+
+type GuardWithOptions = std.GuardWithOptions
 
 func Authn(guard Guard) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		header := c.Request.Header.Get("Authorization")
-		if header == "" {
-			c.Next()
-			return
-		}
+	return AuthnWithOptions(guard, auth.AuthnOptions{})
+}
 
-		parts := strings.Split(header, "Bearer")
-		if len(parts) != 2 {
-			c.Next()
-			return
-		}
+// AuthnWithOptions reads the token with opts.Extractor, see auth.FirstOf to accept cookies or query parameters.
+func AuthnWithOptions(guard Guard, opts auth.AuthnOptions) gin.HandlerFunc {
+	return authn(guard.AuthenticateJWT, opts)
+}
 
-		token := strings.TrimSpace(parts[1])
-		if len(token) == 0 {
-			c.Next()
-			return
-		}
+// AuthnWithValidation authenticates with its own claim validation, e.g. a route only accepting a given audience.
+func AuthnWithValidation(guard GuardWithOptions, validation jwtx.ValidationOptions) gin.HandlerFunc {
+	return AuthnWithValidationOptions(guard, validation, auth.AuthnOptions{})
+}
 
-		_, claims, err := guard.AuthenticateJWT(c, token)
+// AuthnWithValidationOptions is AuthnWithValidation reading the token with opts.Extractor.
+func AuthnWithValidationOptions(guard GuardWithOptions, validation jwtx.ValidationOptions, opts auth.AuthnOptions) gin.HandlerFunc {
+	return authn(std.AuthenticatorWithValidation(guard, validation), opts)
+}
+
+func authn(authenticate auth.Authenticator, opts auth.AuthnOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, err := auth.Authenticate(c.Request, authenticate, opts)
 		if err != nil {
-			// although it's a client error, we don't want to detailed information
-			Abort(c, errorx.Wrap(errors.New("invalid access token"), errorx.Authn), -1)
+			Abort(c, err)
 			return
 		}
 
-		ctx := c.Request.Context()
-		ctx = auth.WithAuthJWT(ctx, token)
-		ctx = auth.WithAuthClaims(ctx, claims)
 		c.Request = c.Request.WithContext(ctx) // we don't want to use gin.Context.Set here for universal context.Context usages
 		c.Next()
 	}
 }
 
-type CaptchaPayload struct {
-	Captcha         string `json:"captcha"`
-	CaptchaFallback string `json:"captcha_fallback"`
-}
+type CaptchaPayload = std.CaptchaPayload
 
-type CaptchaVerifyResponse struct {
-	Success     bool      `json:"success"`
-	Score       float64   `json:"score"`
-	Action      string    `json:"action"`
-	ChallengeTS time.Time `json:"challenge_ts"`
-	Hostname    string    `json:"hostname"`
-	ErrorCodes  []string  `json:"error-codes"`
-}
+type CaptchaVerifyResponse = std.CaptchaVerifyResponse
 
-type CaptchaRequirement struct {
-	Secret         string
-	Score          float64
-	Action         string
-	FallbackSecret string
-	Bypass         string
-}
+type CaptchaRequirement = std.CaptchaRequirement
 
+// CaptchaValid verifies the captcha of the JSON body, see std.CaptchaRequirement.
 func CaptchaValid(requirement CaptchaRequirement) gin.HandlerFunc {
+	verifier, fallback, invalid := requirement.Verifiers()
+
 	return func(c *gin.Context) {
-		if requirement.Action == "" {
-			Abort(c, errorx.Wrap(errors.New("missing captcha action"), errorx.Service))
+		if invalid != nil {
+			Abort(c, invalid)
 			return
 		}
 
-		v := c.Request.Header.Get("x-captcha-internal")
-		if requirement.Bypass != "" && requirement.Bypass == v {
+		if std.CaptchaBypassed(c.Request, requirement) {
 			c.Next()
 			return
 		}
 
-		if requirement.Secret == "" {
-			Abort(c, errorx.Wrap(errors.New("missing captcha secret"), errorx.Service))
-			return
-		}
-
 		var payload CaptchaPayload
 		if err := c.ShouldBindBodyWith(&payload, binding.JSON); err != nil {
 			Abort(c, errorx.Wrap(err, errorx.Invalid))
 			return
 		}
 
-		if payload.CaptchaFallback != "" {
-			resp, err := http.PostForm("https://www.google.com/recaptcha/api/siteverify", url.Values{
-				"secret":   {requirement.FallbackSecret},
-				"response": {payload.CaptchaFallback},
-			})
-			if err != nil {
-				Abort(c, errorx.Wrap(err, errorx.Service))
-				return
-			}
-			defer resp.Body.Close()
-
-			var body CaptchaVerifyResponse
-			if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
-				Abort(c, errorx.Wrap(err, errorx.Service))
-				return
-			}
-
-			if !body.Success {
-				Abort(c, errorx.Wrap(errors.New("fallback captcha failed"), errorx.Captcha))
-				return
-			}
-
-			// TODO check body.ChallengeTS
-			c.Next()
-			return
-		}
-
-		resp, err := http.PostForm("https://www.google.com/recaptcha/api/siteverify", url.Values{
-			"secret":   {requirement.Secret},
-			"response": {payload.Captcha},
-		})
-		if err != nil {
-			Abort(c, errorx.Wrap(err, errorx.Service))
-			return
-		}
-		defer resp.Body.Close()
-
-		var body CaptchaVerifyResponse
-		if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
-			Abort(c, errorx.Wrap(err, errorx.Service))
-			return
-		}
-
-		if body.Action == "" || (requirement.Action != "" && body.Action != requirement.Action) {
-			Abort(c, errorx.Wrap(errors.New("captcha failed"), errorx.Captcha))
-			return
-		}
-
-		if body.Score <= 0 || !body.Success || body.Score < requirement.Score {
-			Abort(c, errorx.Wrap(errors.New("captcha failed"), errorx.Captcha))
+		if err := std.VerifyCaptcha(c.Request.Context(), verifier, fallback, payload); err != nil {
+			Abort(c, err)
 			return
 		}
 
-		// TODO check body.ChallengeTS
 		c.Next()
 	}
 }
 
-type ctxKey string
-
-const (
-	ctxKeyCSPNonce ctxKey = "csp-nonce"
-)
+func DisableLimiter() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(limiter.Skip(c.Request.Context()))
+		c.Next()
+	}
+}
 
 func CSP(secureMiddleware *secure.Secure) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -182,11 +107,11 @@ func CSP(secureMiddleware *secure.Secure) gin.HandlerFunc {
 			return
 		}
 
-		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ctxKeyCSPNonce, nonce))
+		c.Request = c.Request.WithContext(std.WithCSPNonce(c.Request.Context(), nonce))
 		c.Next()
 	}
 }
 
 func CSPNonce(ctx context.Context) any {
-	return ctx.Value(ctxKeyCSPNonce)
+	return std.CSPNonce(ctx)
 }
@@ -0,0 +1,25 @@
+package httpx
+
+import (
+	"github.com/gin-gonic/gin"
+	std "github.com/hiendaovinh/toolkit/pkg/httpx-std"
+)
+
+type RateLimitOptions = std.RateLimitOptions
+
+// RateLimit enforces opts.Limit, see std.CheckRateLimit. std.KeyByRoute uses the gin full path.
+func RateLimit(opts RateLimitOptions) (gin.HandlerFunc, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		r := c.Request.WithContext(std.WithRoute(c.Request.Context(), c.FullPath()))
+		if err := std.CheckRateLimit(c.Writer.Header(), r, opts); err != nil {
+			Abort(c, err)
+			return
+		}
+
+		c.Next()
+	}, nil
+}
@@ -0,0 +1,53 @@
+package httpx_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis_rate/v10"
+	httpx "github.com/hiendaovinh/toolkit/pkg/httpx-gin"
+	std "github.com/hiendaovinh/toolkit/pkg/httpx-std"
+	"github.com/hiendaovinh/toolkit/pkg/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	l, err := limiter.NewLimiterMemory(ctx, limiter.LimiterMemoryOptions{})
+	assert.Nil(t, err, "should be successful")
+
+	_, err = httpx.RateLimit(httpx.RateLimitOptions{Limiter: l})
+	assert.ErrorIs(t, err, limiter.ErrInvalidLimit)
+
+	middleware, err := httpx.RateLimit(httpx.RateLimitOptions{Limiter: l, Limit: redis_rate.PerMinute(1), Key: std.KeyByRoute()})
+	assert.Nil(t, err, "should be successful")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware)
+	handler := func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}
+	router.GET("/articles/:id", handler)
+	router.GET("/users/:id", handler)
+
+	serve := func(path string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	// the budget is per route template, not per path
+	assert.Equal(t, http.StatusOK, serve("/articles/1"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("/articles/2"))
+	assert.Equal(t, http.StatusOK, serve("/users/1"))
+
+	// unknown paths share a single budget
+	assert.Equal(t, http.StatusNotFound, serve("/missing/1"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("/missing/2"))
+}
@@ -2,64 +2,29 @@ package httpx
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/hiendaovinh/toolkit/pkg/errorx"
+	std "github.com/hiendaovinh/toolkit/pkg/httpx-std"
 )
 
-type body struct {
-	Code    string `json:"code,omitempty"`
-	Message string `json:"message,omitempty"`
-	Data    any    `json:"data,omitempty"`
-}
-
 func Abort(c *gin.Context, v any, codes ...int) {
 	code := -1
 	if len(codes) >= 1 {
 		code = codes[0]
 	}
 
-	err, ok := v.(error)
-	if ok {
-		abortErrorWithStatusJSON(c, err, code)
-		return
-	}
-
-	c.AbortWithStatusJSON(code, &body{Data: v})
-}
-
-func abortErrorWithStatusJSON(c *gin.Context, err error, code int) {
-	var target *errorx.Error
-
-	message := errorx.MaskErrorMessage(err)
-
-	if !errors.As(err, &target) {
-		//nolint:errcheck
-		c.Error(err)
-		if code == -1 {
-			code = http.StatusInternalServerError
-		}
-		c.AbortWithStatusJSON(code, &body{Code: "error", Message: message})
-		return
-	}
-
-	if code == -1 {
-		code = target.Status()
-	}
+	std.SetRetryAfter(c.Writer.Header(), v)
 
-	if target.Of(errorx.Database) || target.Of(errorx.Service) {
+	code, body, err := std.Render(v, code)
+	if err != nil {
 		//nolint:errcheck
 		c.Error(err)
-		c.AbortWithStatusJSON(code, &body{Code: target.Code(), Message: message})
-		return
 	}
 
-	c.AbortWithStatusJSON(code, &body{Code: target.Code(), Message: message})
+	c.AbortWithStatusJSON(code, body)
 }
 
 type Validator interface {
@@ -0,0 +1,24 @@
+package httpx
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+)
+
+// JWKS serves the Authority keys, usually mounted at jwtx.PathJWKS.
+func JWKS(authority *jwtx.Authority, maxAge time.Duration) gin.HandlerFunc {
+	return gin.WrapH(jwtx.JWKSHandler(authority, maxAge))
+}
+
+// Discovery serves the OpenID configuration, usually mounted at jwtx.PathDiscovery.
+func Discovery(authority *jwtx.Authority, maxAge time.Duration) gin.HandlerFunc {
+	return gin.WrapH(jwtx.DiscoveryHandler(authority, maxAge))
+}
+
+// WellKnown mounts both documents.
+func WellKnown(r gin.IRoutes, authority *jwtx.Authority, maxAge time.Duration) {
+	r.GET(jwtx.PathJWKS, JWKS(authority, maxAge))
+	r.GET(jwtx.PathDiscovery, Discovery(authority, maxAge))
+}
@@ -0,0 +1,50 @@
+package httpx
+
+import (
+	"net/http"
+
+	"github.com/hiendaovinh/toolkit/pkg/auth"
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+)
+
+// ContextBuilder returns the ladon request context, e.g. the owner of the resource.
+type ContextBuilder func(r *http.Request) (map[string]any, error)
+
+// Authz requires an authenticated subject allowed to act on the resource, see Authn.
+// Placeholders of the resource template like articles:{id} are filled from r.PathValue,
+// set by http.ServeMux patterns and chi >= 5.1. The action defaults to auth.ActionFromMethod and build may be nil.
+func Authz(guard auth.Authorizer, resource string, action string, build ContextBuilder) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := auth.ExpandResource(resource, r.PathValue)
+			if err != nil {
+				//nolint:errcheck
+				Abort(w, r, errorx.Wrap(err, errorx.Service))
+				return
+			}
+
+			a := action
+			if a == "" {
+				a = auth.ActionFromMethod(r.Method)
+			}
+
+			var ctx map[string]any
+			if build != nil {
+				ctx, err = build(r)
+				if err != nil {
+					//nolint:errcheck
+					RestAbort(w, r, nil, err)
+					return
+				}
+			}
+
+			if err := auth.Authorize(r.Context(), guard, res, a, ctx); err != nil {
+				//nolint:errcheck
+				Abort(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/hiendaovinh/toolkit/pkg/captcha"
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+)
+
+// HeaderCaptchaBypass carries CaptchaRequirement.Bypass for internal callers.
+const HeaderCaptchaBypass = "x-captcha-internal"
+
+type CaptchaPayload struct {
+	Captcha         string `json:"captcha"`
+	CaptchaFallback string `json:"captcha_fallback"`
+}
+
+type CaptchaVerifyResponse = captcha.Response
+
+type CaptchaRequirement struct {
+	// Verifier checks CaptchaPayload.Captcha, built from Secret, Score and Action as reCAPTCHA v3 when nil.
+	Verifier captcha.Verifier
+	// Fallback checks CaptchaPayload.CaptchaFallback, built from FallbackSecret as reCAPTCHA v2 when nil.
+	Fallback captcha.Verifier
+
+	Secret         string
+	Score          float64
+	Action         string
+	FallbackSecret string
+	Bypass         string
+}
+
+// Verifiers returns the verifiers of the requirement, the fallback may be nil.
+// The reCAPTCHA v3 verifier built from Secret requires Action.
+func (requirement CaptchaRequirement) Verifiers() (captcha.Verifier, captcha.Verifier, error) {
+	verifier, fallback := requirement.Verifier, requirement.Fallback
+	if verifier == nil {
+		if requirement.Secret == "" {
+			return nil, nil, errorx.Wrap(errors.New("missing captcha secret"), errorx.Service)
+		}
+
+		if requirement.Action == "" {
+			return nil, nil, errorx.Wrap(errors.New("missing captcha action"), errorx.Service)
+		}
+
+		v, err := captcha.NewRecaptchaV3(captcha.Options{Secret: requirement.Secret, Score: requirement.Score, Action: requirement.Action})
+		if err != nil {
+			return nil, nil, errorx.Wrap(err, errorx.Service)
+		}
+		verifier = v
+	}
+
+	if fallback == nil && requirement.FallbackSecret != "" {
+		v, err := captcha.NewRecaptchaV2(captcha.Options{Secret: requirement.FallbackSecret})
+		if err != nil {
+			return nil, nil, errorx.Wrap(err, errorx.Service)
+		}
+		fallback = v
+	}
+
+	return verifier, fallback, nil
+}
+
+// CaptchaBypassed reports whether the request carries the bypass secret.
+func CaptchaBypassed(r *http.Request, requirement CaptchaRequirement) bool {
+	return requirement.Bypass != "" && requirement.Bypass == r.Header.Get(HeaderCaptchaBypass)
+}
+
+// VerifyCaptcha checks payload.Captcha, or payload.CaptchaFallback when set.
+// The returned error is already classified for Abort.
+func VerifyCaptcha(ctx context.Context, verifier captcha.Verifier, fallback captcha.Verifier, payload CaptchaPayload) error {
+	if payload.CaptchaFallback != "" {
+		if fallback == nil {
+			return errorx.Wrap(errors.New("fallback captcha unsupported"), errorx.Captcha)
+		}
+
+		_, err := fallback.Verify(ctx, payload.CaptchaFallback)
+		return err
+	}
+
+	_, err := verifier.Verify(ctx, payload.Captcha)
+	return err
+}
+
+// ReadCaptchaPayload decodes the JSON body and restores it for the next handler.
+func ReadCaptchaPayload(r *http.Request) (CaptchaPayload, error) {
+	var payload CaptchaPayload
+	if r.Body == nil {
+		return payload, errorx.Wrap(errors.New("missing body"), errorx.Invalid)
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return payload, errorx.Wrap(err, errorx.Invalid)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(b))
+
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return payload, errorx.Wrap(err, errorx.Invalid)
+	}
+
+	return payload, nil
+}
+
+func CaptchaValid(requirement CaptchaRequirement) Middleware {
+	verifier, fallback, invalid := requirement.Verifiers()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if invalid != nil {
+				//nolint:errcheck
+				Abort(w, r, invalid)
+				return
+			}
+
+			if CaptchaBypassed(r, requirement) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			payload, err := ReadCaptchaPayload(r)
+			if err != nil {
+				//nolint:errcheck
+				Abort(w, r, err)
+				return
+			}
+
+			if err := VerifyCaptcha(r.Context(), verifier, fallback, payload); err != nil {
+				//nolint:errcheck
+				Abort(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
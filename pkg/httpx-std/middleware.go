@@ -0,0 +1,102 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hiendaovinh/toolkit/pkg/auth"
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+	"github.com/hiendaovinh/toolkit/pkg/limiter"
+	"github.com/unrolled/secure"
+)
+
+type Guard interface {
+	AuthenticateJWT(ctx context.Context, tokenStr string) (*jwt.Token, *jwtx.JWTClaims, error)
+}
+
+type GuardWithOptions interface {
+	AuthenticateJWTWithOptions(ctx context.Context, tokenStr string, opts jwtx.ValidationOptions) (*jwt.Token, *jwtx.JWTClaims, error)
+}
+
+// Middleware is the net/http middleware signature, compatible with chi.
+type Middleware func(next http.Handler) http.Handler
+
+func Authn(guard Guard) Middleware {
+	return AuthnWithOptions(guard, auth.AuthnOptions{})
+}
+
+// AuthnWithOptions reads the token with opts.Extractor, see auth.FirstOf to accept cookies or query parameters.
+func AuthnWithOptions(guard Guard, opts auth.AuthnOptions) Middleware {
+	return authn(guard.AuthenticateJWT, opts)
+}
+
+// AuthnWithValidation authenticates with its own claim validation, e.g. a route only accepting a given audience.
+func AuthnWithValidation(guard GuardWithOptions, validation jwtx.ValidationOptions) Middleware {
+	return AuthnWithValidationOptions(guard, validation, auth.AuthnOptions{})
+}
+
+// AuthnWithValidationOptions is AuthnWithValidation reading the token with opts.Extractor.
+func AuthnWithValidationOptions(guard GuardWithOptions, validation jwtx.ValidationOptions, opts auth.AuthnOptions) Middleware {
+	return authn(AuthenticatorWithValidation(guard, validation), opts)
+}
+
+// AuthenticatorWithValidation binds the validation options of guard, shared by the framework adapters.
+func AuthenticatorWithValidation(guard GuardWithOptions, validation jwtx.ValidationOptions) auth.Authenticator {
+	return func(ctx context.Context, tokenStr string) (*jwt.Token, *jwtx.JWTClaims, error) {
+		return guard.AuthenticateJWTWithOptions(ctx, tokenStr, validation)
+	}
+}
+
+func authn(authenticate auth.Authenticator, opts auth.AuthnOptions) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, err := auth.Authenticate(r, authenticate, opts)
+			if err != nil {
+				//nolint:errcheck
+				Abort(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func DisableLimiter() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(limiter.Skip(r.Context())))
+		})
+	}
+}
+
+type ctxKey string
+
+const (
+	ctxKeyCSPNonce ctxKey = "csp-nonce"
+	ctxKeyRoute    ctxKey = "route"
+)
+
+func WithCSPNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, ctxKeyCSPNonce, nonce)
+}
+
+func CSPNonce(ctx context.Context) any {
+	return ctx.Value(ctxKeyCSPNonce)
+}
+
+// CSP applies secureMiddleware and exposes its nonce, see CSPNonce.
+// The response is already written when secureMiddleware rejects the request.
+func CSP(secureMiddleware *secure.Secure) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nonce, err := secureMiddleware.ProcessAndReturnNonce(w, r)
+			if err != nil {
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithCSPNonce(r.Context(), nonce)))
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package httpx_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hiendaovinh/toolkit/pkg/auth"
+	"github.com/hiendaovinh/toolkit/pkg/captcha"
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+	httpx "github.com/hiendaovinh/toolkit/pkg/httpx-std"
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+	"github.com/hiendaovinh/toolkit/pkg/limiter"
+	"github.com/ory/ladon"
+	"github.com/stretchr/testify/assert"
+)
+
+type guardFunc func(tokenStr string) (*jwtx.JWTClaims, error)
+
+func (f guardFunc) AuthenticateJWT(ctx context.Context, tokenStr string) (*jwt.Token, *jwtx.JWTClaims, error) {
+	claims, err := f(tokenStr)
+	return nil, claims, err
+}
+
+func (f guardFunc) AuthenticateJWTWithOptions(ctx context.Context, tokenStr string, opts jwtx.ValidationOptions) (*jwt.Token, *jwtx.JWTClaims, error) {
+	claims, err := f(tokenStr)
+	if err == nil && claims.Issuer != opts.Issuer {
+		err = errors.New("invalid issuer")
+	}
+
+	return nil, claims, err
+}
+
+type authorizerFunc func(sub string, resource string, action string) error
+
+func (f authorizerFunc) AllowContext(ctx context.Context, sub string, resource string, action string, c map[string]any) error {
+	return f(sub, resource, action)
+}
+
+type verifierFunc func(token string) error
+
+func (f verifierFunc) Verify(ctx context.Context, token string) (*captcha.Response, error) {
+	return &captcha.Response{Success: true}, f(token)
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) httpx.Body {
+	var body httpx.Body
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&body), "should be successful")
+	return body
+}
+
+func TestAbort(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	rec := httptest.NewRecorder()
+	assert.Nil(t, httpx.Abort(rec, r, map[string]any{"foo": "bar"}))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, map[string]any{"foo": "bar"}, decode(t, rec).Data)
+
+	rec = httptest.NewRecorder()
+	assert.Nil(t, httpx.Abort(rec, r, errorx.Wrap(errors.New("not found"), errorx.NotExist)))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, httpx.Body{Code: "resource-not-found", Message: "not found"}, decode(t, rec))
+
+	rec = httptest.NewRecorder()
+	assert.Nil(t, httpx.RestAbort(rec, r, nil, errors.New("connection refused")))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, httpx.Body{Code: "internal-service-failure", Message: "unable to process"}, decode(t, rec))
+
+	rec = httptest.NewRecorder()
+	assert.Nil(t, httpx.RestAbort(rec, r, nil, &limiter.RateLimitedError{RetryAfter: 1500 * time.Millisecond}))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+}
+
+func TestAuthnAuthz(t *testing.T) {
+	guard := guardFunc(func(tokenStr string) (*jwtx.JWTClaims, error) {
+		if tokenStr != "valid" {
+			return nil, errors.New("invalid")
+		}
+
+		return &jwtx.JWTClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "foo"}}, nil
+	})
+
+	authorizer := authorizerFunc(func(sub string, resource string, action string) error {
+		if sub == "foo" && resource == "articles:1" && action == "read" {
+			return nil
+		}
+
+		return ladon.ErrRequestDenied
+	})
+
+	mux := http.NewServeMux()
+	handler := httpx.Authz(authorizer, "articles:{id}", "", nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	mux.Handle("/articles/{id}", httpx.Authn(guard)(handler))
+
+	serve := func(path string, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := serve("/articles/1", "valid")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+
+	assert.Equal(t, http.StatusForbidden, serve("/articles/2", "valid").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("/articles/1", "invalid").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("/articles/1", "").Code)
+}
+
+func TestAuthnWithValidationOptions(t *testing.T) {
+	guard := guardFunc(func(tokenStr string) (*jwtx.JWTClaims, error) {
+		return &jwtx.JWTClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "foo", Issuer: tokenStr}}, nil
+	})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})
+	m := httpx.AuthnWithValidationOptions(guard, jwtx.ValidationOptions{Issuer: "issuer"}, auth.AuthnOptions{Extractor: auth.FromCookie("token")})
+
+	serve := func(token string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: "token", Value: token})
+
+		rec := httptest.NewRecorder()
+		m(handler).ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("issuer"))
+	assert.Equal(t, http.StatusUnauthorized, serve("other"))
+}
+
+func TestCaptchaValid(t *testing.T) {
+	requirement := httpx.CaptchaRequirement{Secret: "secret", Action: "login", Bypass: "internal"}
+	handler := httpx.CaptchaValid(requirement)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"foo"}`))
+	r.Header.Set(httpx.HeaderCaptchaBypass, "internal")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"name":"foo"}`, rec.Body.String())
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{`))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	verifier := verifierFunc(func(token string) error {
+		if token != "human" {
+			return errorx.Wrap(captcha.ErrFailed, errorx.Captcha)
+		}
+
+		return nil
+	})
+	handler = httpx.CaptchaValid(httpx.CaptchaRequirement{Verifier: verifier})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"captcha":"human"}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"captcha":"bot"}`)))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"captcha_fallback":"human"}`)))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, "no fallback verifier")
+
+	// the legacy reCAPTCHA v3 verifier requires an action
+	_, _, err := httpx.CaptchaRequirement{Secret: "secret"}.Verifiers()
+	assert.ErrorContains(t, err, "missing captcha action")
+
+	payload, err := httpx.ReadCaptchaPayload(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"captcha":"token"}`)))
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, "token", payload.Captcha)
+}
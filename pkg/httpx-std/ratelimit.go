@@ -0,0 +1,235 @@
+package httpx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis_rate/v10"
+	"github.com/hiendaovinh/toolkit/pkg/auth"
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+	"github.com/hiendaovinh/toolkit/pkg/limiter"
+)
+
+// A KeyFunc returns the rate limit key of the request, an empty key skips the limit.
+type KeyFunc func(r *http.Request) (string, error)
+
+// ClientIP returns the address of the client. X-Forwarded-For is only honored when the peer
+// is one of the trusted proxies, it is read from the right up to the first untrusted hop.
+func ClientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	addr = addr.Unmap()
+
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+
+	for i := len(hops); isTrusted(addr, trusted) && i > 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i-1]))
+		if err != nil {
+			break
+		}
+
+		addr = hop.Unmap()
+	}
+
+	return addr.String()
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// KeyByIP limits per client, see ClientIP.
+func KeyByIP(trusted ...netip.Prefix) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		return "ip:" + ClientIP(r, trusted), nil
+	}
+}
+
+// KeyBySubject limits per authenticated subject, see Authn. Anonymous requests are not limited,
+// combine it with KeyByIP in another middleware to cover them.
+func KeyBySubject() KeyFunc {
+	return func(r *http.Request) (string, error) {
+		sub := auth.ResolveSubject(r.Context())
+		if sub == "" {
+			return "", nil
+		}
+
+		return "sub:" + sub, nil
+	}
+}
+
+// KeyByAPIKey limits per API key, e.g. auth.FromHeader("X-Api-Key"). The key is hashed, requests without one are not limited.
+func KeyByAPIKey(extract auth.Extractor) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		key := extract(r)
+		if key == "" {
+			return "", nil
+		}
+
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:16]), nil
+	}
+}
+
+// WithRoute sets the route template of the request, the framework adapters set it for KeyByRoute.
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, ctxKeyRoute, route)
+}
+
+// RouteUnmatched is the route of KeyByRoute for requests without a route template, e.g. not found.
+const RouteUnmatched = "unmatched"
+
+// KeyByRoute limits per route template and method, e.g. "GET /articles/{id}".
+// The route falls back to the http.ServeMux pattern, then to RouteUnmatched: unmatched paths share a single budget.
+func KeyByRoute() KeyFunc {
+	return func(r *http.Request) (string, error) {
+		route, _ := r.Context().Value(ctxKeyRoute).(string)
+		if route == "" {
+			route = r.Pattern
+		}
+
+		if route == "" {
+			route = RouteUnmatched
+		}
+
+		return "route:" + r.Method + " " + route, nil
+	}
+}
+
+// KeyJoin combines keys, e.g. a limit per route and client. Any empty key skips the limit.
+func KeyJoin(keys ...KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			v, err := key(r)
+			if err != nil || v == "" {
+				return "", err
+			}
+
+			parts[i] = v
+		}
+
+		return strings.Join(parts, "|"), nil
+	}
+}
+
+type RateLimitOptions struct {
+	Limiter limiter.Limiter
+	Limit   redis_rate.Limit
+	// Key defaults to KeyByIP without trusted proxies.
+	Key KeyFunc
+	// Name separates the budgets of middlewares sharing a key, e.g. two routes limited per IP.
+	Name string
+}
+
+// Validate reports the options RateLimit refuses.
+func (opts RateLimitOptions) Validate() error {
+	if opts.Limiter == nil {
+		return errors.New("invalid limiter")
+	}
+
+	return limiter.ValidateLimit(opts.Limit)
+}
+
+// CheckRateLimit consumes the budget of the request and writes the RateLimit-* headers.
+// The returned error is already classified for Abort, which sets Retry-After.
+func CheckRateLimit(header http.Header, r *http.Request, opts RateLimitOptions) error {
+	if limiter.Skipped(r.Context()) {
+		return nil
+	}
+
+	keyFunc := opts.Key
+	if keyFunc == nil {
+		keyFunc = KeyByIP()
+	}
+
+	key, err := keyFunc(r)
+	if err != nil {
+		return Classify(err)
+	}
+
+	if key == "" {
+		return nil
+	}
+
+	if opts.Name != "" {
+		key = opts.Name + ":" + key
+	}
+
+	res, err := opts.Limiter.AllowResult(r.Context(), key, opts.Limit)
+	if res != nil {
+		header.Set("RateLimit-Limit", strconv.Itoa(res.Limit.Burst))
+		header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		header.Set("RateLimit-Reset", seconds(res.ResetAfter))
+	}
+
+	if errors.Is(err, limiter.ErrRateLimited) {
+		return errorx.Wrap(err, errorx.RateLimiting)
+	}
+
+	if err != nil {
+		return errorx.Wrap(err, errorx.Service)
+	}
+
+	return nil
+}
+
+// SetRetryAfter sets the Retry-After header when v is a limiter.RateLimitedError.
+func SetRetryAfter(header http.Header, v any) {
+	err, ok := v.(error)
+	if !ok {
+		return
+	}
+
+	if d, ok := limiter.RetryAfter(err); ok && d > 0 {
+		header.Set("Retry-After", seconds(d))
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(max(d, 0).Seconds())))
+}
+
+// RateLimit enforces opts.Limit, apply it per route or group for per-route limits.
+func RateLimit(opts RateLimitOptions) (Middleware, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := CheckRateLimit(w.Header(), r, opts); err != nil {
+				//nolint:errcheck
+				Abort(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
@@ -0,0 +1,102 @@
+package httpx_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/go-redis/redis_rate/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hiendaovinh/toolkit/pkg/auth"
+	httpx "github.com/hiendaovinh/toolkit/pkg/httpx-std"
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+	"github.com/hiendaovinh/toolkit/pkg/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "203.0.113.1:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	assert.Equal(t, "203.0.113.1", httpx.ClientIP(r, trusted), "untrusted peers can not forward")
+
+	r.RemoteAddr = "10.0.0.2:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.1, 10.0.0.1")
+	assert.Equal(t, "203.0.113.1", httpx.ClientIP(r, trusted), "spoofed hops left of the first untrusted one are ignored")
+
+	r.Header.Set("X-Forwarded-For", "garbage")
+	assert.Equal(t, "10.0.0.2", httpx.ClientIP(r, trusted))
+}
+
+func TestKeys(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/articles/1", nil)
+	r.Header.Set("X-Api-Key", "secret")
+
+	key, err := httpx.KeyBySubject()(r)
+	assert.Nil(t, err, "should be successful")
+	assert.Empty(t, key, "anonymous requests are not limited")
+
+	ctx := auth.WithAuthClaims(r.Context(), &jwtx.JWTClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "foo"}})
+	key, err = httpx.KeyBySubject()(r.WithContext(ctx))
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, "sub:foo", key)
+
+	key, err = httpx.KeyByAPIKey(auth.FromHeader("X-Api-Key"))(r)
+	assert.Nil(t, err, "should be successful")
+	assert.NotContains(t, key, "secret")
+
+	key, err = httpx.KeyJoin(httpx.KeyByRoute(), httpx.KeyByIP())(r.WithContext(httpx.WithRoute(r.Context(), "/articles/:id")))
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, "route:GET /articles/:id|ip:192.0.2.1", key)
+
+	// paths without a route share a budget instead of one per path
+	key, err = httpx.KeyByRoute()(r)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, "route:GET "+httpx.RouteUnmatched, key)
+}
+
+func TestRateLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	l, err := limiter.NewLimiterMemory(ctx, limiter.LimiterMemoryOptions{})
+	assert.Nil(t, err, "should be successful")
+
+	_, err = httpx.RateLimit(httpx.RateLimitOptions{Limit: redis_rate.PerMinute(2)})
+	assert.EqualError(t, err, "invalid limiter")
+
+	_, err = httpx.RateLimit(httpx.RateLimitOptions{Limiter: l})
+	assert.ErrorIs(t, err, limiter.ErrInvalidLimit)
+
+	name := "test:" + t.Name()
+	middleware, err := httpx.RateLimit(httpx.RateLimitOptions{Limiter: l, Limit: redis_rate.PerMinute(2), Name: name})
+	assert.Nil(t, err, "should be successful")
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := serve(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, serve(httptest.NewRequest(http.MethodGet, "/", nil)).Code)
+
+	rec = serve(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec = serve(r.WithContext(limiter.Skip(r.Context())))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
@@ -0,0 +1,103 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/hiendaovinh/toolkit/pkg/auth"
+	"github.com/hiendaovinh/toolkit/pkg/errorx"
+	"github.com/hiendaovinh/toolkit/pkg/limiter"
+)
+
+// Body is the response envelope shared by httpx-std, httpx-echo and httpx-gin.
+type Body struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Render returns the status and the envelope of v, code -1 picks the status from v.
+// The returned error is set when v is an error worth logging: unexpected, database or service errors.
+func Render(v any, code int) (int, *Body, error) {
+	err, ok := v.(error)
+	if !ok {
+		if code == -1 {
+			code = http.StatusOK
+		}
+
+		return code, &Body{Data: v}, nil
+	}
+
+	var target *errorx.Error
+
+	message := errorx.MaskErrorMessage(err)
+
+	if !errors.As(err, &target) {
+		if code == -1 {
+			code = http.StatusInternalServerError
+		}
+
+		return code, &Body{Code: "error", Message: message}, err
+	}
+
+	if code == -1 {
+		code = target.Status()
+	}
+
+	if target.Of(errorx.Database) || target.Of(errorx.Service) {
+		return code, &Body{Code: target.Code(), Message: message}, err
+	}
+
+	return code, &Body{Code: target.Code(), Message: message}, nil
+}
+
+// Classify wraps a handler error into the errorx.Kind the envelope expects.
+func Classify(err error) error {
+	if errors.Is(err, auth.ErrInvalidSession) {
+		return errorx.Wrap(err, errorx.Authn)
+	}
+
+	if errors.Is(err, limiter.ErrRateLimited) {
+		return errorx.Wrap(err, errorx.RateLimiting)
+	}
+
+	var target *errorx.Error
+	if errors.As(err, &target) {
+		return err
+	}
+
+	return errorx.Wrap(err, errorx.Service)
+}
+
+func WriteJSON(w http.ResponseWriter, code int, v any) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	return json.NewEncoder(w).Encode(v)
+}
+
+// Abort writes v in the envelope, errors are logged with slog.
+func Abort(w http.ResponseWriter, r *http.Request, v any, codes ...int) error {
+	code := -1
+	if len(codes) >= 1 {
+		code = codes[0]
+	}
+
+	SetRetryAfter(w.Header(), v)
+
+	code, body, err := Render(v, code)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+
+	return WriteJSON(w, code, body)
+}
+
+func RestAbort(w http.ResponseWriter, r *http.Request, v any, err error) error {
+	if err != nil {
+		return Abort(w, r, Classify(err))
+	}
+
+	return Abort(w, r, v)
+}
@@ -2,16 +2,18 @@ package jwtx
 
 import (
 	"context"
+	"crypto"
 	"crypto/ed25519"
-	"crypto/sha256"
-	"encoding/base64"
 	"errors"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/MicahParks/jwkset"
 	"github.com/MicahParks/keyfunc/v3"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/hiendaovinh/toolkit/pkg/cert"
 )
 
 type JWK struct {
@@ -30,19 +32,106 @@ type JWTClaims struct {
 var (
 	ErrUnableToParse = errors.New("unable to parse")
 	ErrInvalidClaims = errors.New("invalid token claims")
+	ErrInvalidKey    = errors.New("invalid key")
+	ErrKeyNotFound   = errors.New("key not found")
+	ErrKeyActive     = errors.New("key is active")
 )
 
+type signingKey struct {
+	id        string
+	signer    crypto.Signer
+	method    jwt.SigningMethod
+	keyType   cert.KeyType
+	createdAt time.Time
+	retiredAt time.Time
+}
+
+// signingMethod maps the supported key types to their JWS algorithm.
+func signingMethod(keyType cert.KeyType) jwt.SigningMethod {
+	switch keyType {
+	case cert.KeyTypeED25519:
+		return jwt.SigningMethodEdDSA
+	case cert.KeyTypeRSA2048, cert.KeyTypeRSA3072, cert.KeyTypeRSA4096:
+		return jwt.SigningMethodRS256
+	case cert.KeyTypeECDSAP256:
+		return jwt.SigningMethodES256
+	case cert.KeyTypeECDSAP384:
+		return jwt.SigningMethodES384
+	}
+
+	return nil
+}
+
+func newSigningKey(signer crypto.Signer) (*signingKey, error) {
+	keyType, err := cert.KeyTypeOf(signer)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	id, err := cert.KeyID(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &signingKey{
+		id:        id,
+		signer:    signer,
+		method:    signingMethod(keyType),
+		keyType:   keyType,
+		createdAt: time.Now(),
+	}, nil
+}
+
+func newED25519SigningKey(pub ed25519.PublicKey, priv ed25519.PrivateKey) (*signingKey, error) {
+	if len(pub) != ed25519.PublicKeySize || len(priv) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidKey
+	}
+
+	if !pub.Equal(priv.Public()) {
+		return nil, ErrInvalidKey
+	}
+
+	return newSigningKey(priv)
+}
+
+// KeyStatus describes a key held by the Authority.
+type KeyStatus struct {
+	ID        string
+	Active    bool
+	CreatedAt time.Time
+	RetiredAt time.Time
+}
+
+// Authority signs tokens with its active key while keeping every other known key
+// (pending or retired) available for verification through the published key set.
 type Authority struct {
 	issuer     string
 	expiration time.Duration
 
-	pub  ed25519.PublicKey
-	priv ed25519.PrivateKey
+	mu     sync.RWMutex
+	active *signingKey
+	keys   []*signingKey
+}
+
+func (g *Authority) activeKey() *signingKey {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.active
+}
+
+func (g *Authority) findKey(id string) *signingKey {
+	for _, k := range g.keys {
+		if k.id == id {
+			return k
+		}
+	}
+
+	return nil
 }
 
-func (g *Authority) kid() string {
-	hash := sha256.Sum256(g.pub)
-	return base64.RawURLEncoding.EncodeToString(hash[:])
+func (g *Authority) Issuer() string {
+	return g.issuer
 }
 
 func (g *Authority) IssueToken(ctx context.Context, subject string, audience []string, metadata map[string]any) (*JWTClaims, string, error) {
@@ -64,49 +153,273 @@ func (g *Authority) IssueToken(ctx context.Context, subject string, audience []s
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
-	token.Header["kid"] = g.kid()
+	key := g.activeKey()
 
-	tokenSigned, err := token.SignedString(g.priv)
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+
+	tokenSigned, err := token.SignedString(key.signer)
 	return claims, tokenSigned, err
 }
 
-func (g *Authority) PublicJWK(ctx context.Context) (jwkset.JWK, error) {
-	id := g.kid()
+// AddKey registers a key for verification without signing with it yet.
+// Publishing the next key ahead of its promotion lets verifiers pick it up before any token uses it.
+func (g *Authority) AddKey(pub ed25519.PublicKey, priv ed25519.PrivateKey) (string, error) {
+	key, err := newED25519SigningKey(pub, priv)
+	if err != nil {
+		return "", err
+	}
+
+	return g.addKey(key), nil
+}
+
+// AddSigner is AddKey for any supported key: ed25519, RSA or ECDSA P-256/P-384.
+func (g *Authority) AddSigner(signer crypto.Signer) (string, error) {
+	key, err := newSigningKey(signer)
+	if err != nil {
+		return "", err
+	}
+
+	return g.addKey(key), nil
+}
+
+func (g *Authority) addKey(key *signingKey) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if existing := g.findKey(key.id); existing != nil {
+		return existing.id
+	}
+
+	g.keys = append(g.keys, key)
+	return key.id
+}
+
+// Promote makes the key the signing key, the previously active key is retired but still published.
+func (g *Authority) Promote(id string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := g.findKey(id)
+	if key == nil {
+		return ErrKeyNotFound
+	}
+
+	if key == g.active {
+		return nil
+	}
+
+	if g.active != nil {
+		g.active.retiredAt = time.Now()
+	}
+
+	key.retiredAt = time.Time{}
+	g.active = key
+	return nil
+}
+
+// Retire marks a non active key as retired, it stays verifiable until removed or pruned.
+func (g *Authority) Retire(id string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := g.findKey(id)
+	if key == nil {
+		return ErrKeyNotFound
+	}
+
+	if key == g.active {
+		return ErrKeyActive
+	}
+
+	if key.retiredAt.IsZero() {
+		key.retiredAt = time.Now()
+	}
+
+	return nil
+}
+
+// SetCreatedAt records when a key was created, e.g. for keys loaded from storage on startup.
+// RotateIfDue counts the age of the active key from it, not from the start of the process.
+func (g *Authority) SetCreatedAt(id string, createdAt time.Time) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := g.findKey(id)
+	if key == nil {
+		return ErrKeyNotFound
+	}
+
+	key.createdAt = createdAt
+	return nil
+}
+
+// Remove drops a non active key, tokens signed by it are no longer verifiable.
+func (g *Authority) Remove(id string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i, k := range g.keys {
+		if k.id != id {
+			continue
+		}
+
+		if k == g.active {
+			return ErrKeyActive
+		}
+
+		g.keys = append(g.keys[:i], g.keys[i+1:]...)
+		return nil
+	}
+
+	return ErrKeyNotFound
+}
+
+// Rotate generates a new key of the same type as the active one and promotes it.
+func (g *Authority) Rotate(ctx context.Context) (string, error) {
+	signer, err := cert.GenerateKey(g.activeKey().keyType)
+	if err != nil {
+		return "", err
+	}
+
+	return g.RotateWith(ctx, signer)
+}
+
+// RotateWith promotes the given key, e.g. to switch algorithms.
+func (g *Authority) RotateWith(ctx context.Context, signer crypto.Signer) (string, error) {
+	id, err := g.AddSigner(signer)
+	if err != nil {
+		return "", err
+	}
+
+	return id, g.Promote(id)
+}
+
+// Prune removes the keys retired for longer than retention and returns their ids.
+func (g *Authority) Prune(retention time.Duration) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	removed := []string{}
+	keys := make([]*signingKey, 0, len(g.keys))
+	for _, k := range g.keys {
+		if k != g.active && !k.retiredAt.IsZero() && now.Sub(k.retiredAt) >= retention {
+			removed = append(removed, k.id)
+			continue
+		}
+
+		keys = append(keys, k)
+	}
+
+	g.keys = keys
+	return removed
+}
+
+// ActiveKeyID returns the id of the key currently used for signing.
+func (g *Authority) ActiveKeyID() string {
+	return g.activeKey().id
+}
+
+// Algorithms lists the JWS algorithms of the held keys, active one first.
+func (g *Authority) Algorithms() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 
+	algs := []string{g.active.method.Alg()}
+	for _, k := range g.keys {
+		if !slices.Contains(algs, k.method.Alg()) {
+			algs = append(algs, k.method.Alg())
+		}
+	}
+
+	return algs
+}
+
+// Keys lists every key held by the Authority.
+func (g *Authority) Keys() []KeyStatus {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	result := make([]KeyStatus, len(g.keys))
+	for i, k := range g.keys {
+		result[i] = KeyStatus{
+			ID:        k.id,
+			Active:    k == g.active,
+			CreatedAt: k.createdAt,
+			RetiredAt: k.retiredAt,
+		}
+	}
+
+	return result
+}
+
+func publicJWK(key *signingKey) (jwkset.JWK, error) {
 	metadata := jwkset.JWKMetadataOptions{
-		ALG: jwkset.AlgEdDSA,
-		KID: id,
+		ALG: jwkset.ALG(key.method.Alg()),
+		KID: key.id,
 		USE: jwkset.UseSig,
 	}
 	options := jwkset.JWKOptions{
 		Metadata: metadata,
 	}
 
-	return jwkset.NewJWKFromKey(g.pub, options)
+	return jwkset.NewJWKFromKey(key.signer.Public(), options)
+}
+
+// PublicJWK returns the public JWK of the active key.
+func (g *Authority) PublicJWK(ctx context.Context) (jwkset.JWK, error) {
+	return publicJWK(g.activeKey())
+}
+
+// PublicJWKs returns the public JWKs of every key, active one first.
+func (g *Authority) PublicJWKs(ctx context.Context) ([]jwkset.JWK, error) {
+	g.mu.RLock()
+	keys := make([]*signingKey, 0, len(g.keys))
+	keys = append(keys, g.active)
+	for _, k := range g.keys {
+		if k != g.active {
+			keys = append(keys, k)
+		}
+	}
+	g.mu.RUnlock()
+
+	jwks := make([]jwkset.JWK, len(keys))
+	for i, k := range keys {
+		jwk, err := publicJWK(k)
+		if err != nil {
+			return nil, err
+		}
+
+		jwks[i] = jwk
+	}
+
+	return jwks, nil
 }
 
 func (g *Authority) GenerateKeySet(ctx context.Context) (*jwkset.MemoryJWKSet, keyfunc.Keyfunc, error) {
 	set := jwkset.NewMemoryStorage()
 
-	jwk, err := g.PublicJWK(ctx)
+	jwks, err := g.PublicJWKs(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	err = set.KeyWrite(ctx, jwk)
-	if err != nil {
-		return nil, nil, err
+	for _, jwk := range jwks {
+		err = set.KeyWrite(ctx, jwk)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
-	jwks, err := keyfunc.New(keyfunc.Options{
+	fnc, err := keyfunc.New(keyfunc.Options{
 		Storage: set,
 	})
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return set, jwks, nil
+	return set, fnc, nil
 }
 
 func (g *Authority) PublicJWKS(ctx context.Context) ([]byte, error) {
@@ -119,6 +432,21 @@ func (g *Authority) PublicJWKS(ctx context.Context) ([]byte, error) {
 }
 
 func NewAuthority(issuer string, expiration time.Duration, pub ed25519.PublicKey, priv ed25519.PrivateKey) (*Authority, error) {
-	// TODO: do some defensive checking here
-	return &Authority{issuer, expiration, pub, priv}, nil
+	key, err := newED25519SigningKey(pub, priv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Authority{issuer: issuer, expiration: expiration, active: key, keys: []*signingKey{key}}, nil
+}
+
+// NewAuthorityFromSigner accepts ed25519, RSA or ECDSA P-256/P-384 keys,
+// tokens are signed with EdDSA, RS256, ES256 or ES384 accordingly.
+func NewAuthorityFromSigner(issuer string, expiration time.Duration, signer crypto.Signer) (*Authority, error) {
+	key, err := newSigningKey(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Authority{issuer: issuer, expiration: expiration, active: key, keys: []*signingKey{key}}, nil
 }
@@ -0,0 +1,90 @@
+package jwtx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	PathJWKS      = "/.well-known/jwks.json"
+	PathDiscovery = "/.well-known/openid-configuration"
+)
+
+// DiscoveryDocument is the subset of the OpenID provider metadata verifiers need to locate the keys.
+type DiscoveryDocument struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// Discovery derives the document from the issuer, which is expected to be the public base URL of the service.
+func (g *Authority) Discovery() DiscoveryDocument {
+	return DiscoveryDocument{
+		Issuer:                           g.issuer,
+		JWKSURI:                          strings.TrimRight(g.issuer, "/") + PathJWKS,
+		ResponseTypesSupported:           []string{"id_token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: g.Algorithms(),
+	}
+}
+
+// JWKSHandler serves the public key set, maxAge drives how often verifiers poll it.
+func JWKSHandler(a *Authority, maxAge time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := a.PublicJWKS(r.Context())
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		serveCacheable(w, r, "application/jwk-set+json", b, maxAge)
+	})
+}
+
+func DiscoveryHandler(a *Authority, maxAge time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := json.Marshal(a.Discovery())
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		serveCacheable(w, r, "application/json", b, maxAge)
+	})
+}
+
+func serveCacheable(w http.ResponseWriter, r *http.Request, contentType string, b []byte, maxAge time.Duration) {
+	hash := sha256.Sum256(b)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(hash[:]) + `"`
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, v := range strings.Split(match, ",") {
+			v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+			if v == etag || v == "*" {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+	}
+
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Length", strconv.Itoa(len(b)))
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method != http.MethodHead {
+		//nolint:errcheck
+		bytes.NewReader(b).WriteTo(w)
+	}
+}
@@ -0,0 +1,70 @@
+package jwtx_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKSHandler(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("https://auth.example.com/", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	h := jwtx.JWKSHandler(a, time.Hour)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, jwtx.PathJWKS, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+
+	expected, err := a.PublicJWKS(context.Background())
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(expected), w.Body.String())
+
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	r := httptest.NewRequest(http.MethodGet, jwtx.PathJWKS, nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+
+	// rotation changes the document, so the etag
+	_, err = a.Rotate(context.Background())
+	assert.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+func TestDiscoveryHandler(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("https://auth.example.com/", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	jwtx.DiscoveryHandler(a, time.Hour).ServeHTTP(w, httptest.NewRequest(http.MethodGet, jwtx.PathDiscovery, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var doc jwtx.DiscoveryDocument
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "https://auth.example.com/", doc.Issuer)
+	assert.Equal(t, "https://auth.example.com/.well-known/jwks.json", doc.JWKSURI)
+	assert.Equal(t, []string{"EdDSA"}, doc.IDTokenSigningAlgValuesSupported)
+}
@@ -2,12 +2,15 @@ package jwtx_test
 
 import (
 	"context"
+	"crypto"
 	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"math/rand"
 	"testing"
 	"time"
 
+	"github.com/hiendaovinh/toolkit/pkg/cert"
 	"github.com/hiendaovinh/toolkit/pkg/jwtx"
 	"github.com/stretchr/testify/assert"
 )
@@ -74,3 +77,198 @@ func TestIssueToken(t *testing.T) {
 
 	assert.LessOrEqual(t, time.Until(claims.ExpiresAt.Time), expiration, "invalid expiration")
 }
+
+func TestKeyRotation(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("issuer", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	first := a.ActiveKeyID()
+	_, tokenOld, err := a.IssueToken(context.Background(), "subject", nil, nil)
+	assert.NoError(t, err)
+
+	second, err := a.Rotate(context.Background())
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, second, a.ActiveKeyID())
+
+	_, tokenNew, err := a.IssueToken(context.Background(), "subject", nil, nil)
+	assert.NoError(t, err)
+
+	_, jwks, err := a.GenerateKeySet(context.Background())
+	assert.NoError(t, err)
+
+	_, _, err = jwtx.ValidateToken(context.Background(), tokenOld, jwks.Keyfunc)
+	assert.NoError(t, err, "retired key should stay verifiable")
+
+	_, _, err = jwtx.ValidateToken(context.Background(), tokenNew, jwks.Keyfunc)
+	assert.NoError(t, err)
+
+	keys, err := a.PublicJWKs(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	assert.Equal(t, second, keys[0].Marshal().KID)
+
+	assert.ErrorIs(t, a.Remove(second), jwtx.ErrKeyActive)
+	assert.ErrorIs(t, a.Promote("unknown"), jwtx.ErrKeyNotFound)
+
+	assert.Equal(t, []string{first}, a.Prune(0))
+
+	_, jwks, err = a.GenerateKeySet(context.Background())
+	assert.NoError(t, err)
+
+	_, _, err = jwtx.ValidateToken(context.Background(), tokenOld, jwks.Keyfunc)
+	assert.Error(t, err, "pruned key should not be verifiable")
+}
+
+func TestRotateIfDue(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("issuer", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	rotated, err := a.RotateIfDue(context.Background(), jwtx.RotationPolicy{Interval: time.Hour})
+	assert.NoError(t, err)
+	assert.False(t, rotated)
+
+	rotated, err = a.RotateIfDue(context.Background(), jwtx.RotationPolicy{Interval: time.Nanosecond})
+	assert.NoError(t, err)
+	assert.True(t, rotated)
+	assert.Len(t, a.Keys(), 2)
+
+	_, err = a.RotateIfDue(context.Background(), jwtx.RotationPolicy{Interval: time.Hour, Retention: time.Nanosecond})
+	assert.NoError(t, err)
+	assert.Len(t, a.Keys(), 1)
+}
+
+func TestRotateIfDuePersisted(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("issuer", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	// the key was created before the process started
+	assert.NoError(t, a.SetCreatedAt(a.ActiveKeyID(), time.Now().Add(-2*time.Hour)))
+	assert.ErrorIs(t, a.SetCreatedAt("missing", time.Now()), jwtx.ErrKeyNotFound)
+
+	persisted := map[string]crypto.Signer{}
+	policy := jwtx.RotationPolicy{Interval: time.Hour, OnRotate: func(ctx context.Context, id string, signer crypto.Signer) error {
+		persisted[id] = signer
+		return nil
+	}}
+
+	rotated, err := a.RotateIfDue(context.Background(), policy)
+	assert.NoError(t, err)
+	assert.True(t, rotated)
+	assert.Contains(t, persisted, a.ActiveKeyID())
+
+	// a key that can not be persisted is not promoted
+	active := a.ActiveKeyID()
+	assert.NoError(t, a.SetCreatedAt(active, time.Now().Add(-2*time.Hour)))
+	policy.OnRotate = func(ctx context.Context, id string, signer crypto.Signer) error {
+		return errors.New("unavailable")
+	}
+
+	rotated, err = a.RotateIfDue(context.Background(), policy)
+	assert.Error(t, err)
+	assert.False(t, rotated)
+	assert.Equal(t, active, a.ActiveKeyID())
+	assert.Len(t, a.Keys(), 2)
+}
+
+func TestValidateTokenWithOptions(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("issuer", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	_, jwks, err := a.GenerateKeySet(context.Background())
+	assert.NoError(t, err)
+
+	_, tokenStr, err := a.IssueToken(context.Background(), "subject", []string{"foo", "bar"}, nil)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		opts  jwtx.ValidationOptions
+		valid bool
+	}{
+		{jwtx.ValidationOptions{}, true},
+		{jwtx.ValidationOptions{Issuer: "issuer", Audiences: []string{"bar", "qux"}}, true},
+		{jwtx.ValidationOptions{Issuer: "other"}, false},
+		{jwtx.ValidationOptions{Audiences: []string{"qux"}}, false},
+		{jwtx.ValidationOptions{RequiredClaims: []string{"exp", "jti", "sub"}}, true},
+		{jwtx.ValidationOptions{Algorithms: []string{"RS256"}}, false},
+	}
+
+	for _, c := range cases {
+		_, _, err := jwtx.ValidateTokenWithOptions(context.Background(), tokenStr, jwks.Keyfunc, c.opts)
+		if c.valid {
+			assert.NoError(t, err, "%+v", c.opts)
+		} else {
+			assert.Error(t, err, "%+v", c.opts)
+		}
+	}
+}
+
+func TestIssueTokenFromSigner(t *testing.T) {
+	cases := map[cert.KeyType]string{
+		cert.KeyTypeED25519:   "EdDSA",
+		cert.KeyTypeRSA2048:   "RS256",
+		cert.KeyTypeECDSAP256: "ES256",
+		cert.KeyTypeECDSAP384: "ES384",
+	}
+
+	for keyType, alg := range cases {
+		signer, err := cert.GenerateKey(keyType)
+		assert.NoError(t, err)
+
+		a, err := jwtx.NewAuthorityFromSigner("issuer", time.Minute, signer)
+		assert.NoError(t, err)
+
+		jwk, err := a.PublicJWK(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, alg, jwk.Marshal().ALG.String())
+
+		_, jwks, err := a.GenerateKeySet(context.Background())
+		assert.NoError(t, err)
+
+		_, tokenStr, err := a.IssueToken(context.Background(), "subject", nil, nil)
+		assert.NoError(t, err)
+
+		token, _, err := jwtx.ValidateTokenWithOptions(context.Background(), tokenStr, jwks.Keyfunc, jwtx.ValidationOptions{Algorithms: a.Algorithms()})
+		assert.NoError(t, err)
+		assert.Equal(t, alg, token.Method.Alg())
+
+		// only EdDSA is accepted by default
+		_, _, err = jwtx.ValidateToken(context.Background(), tokenStr, jwks.Keyfunc)
+		assert.Equal(t, alg == "EdDSA", err == nil)
+
+		_, _, err = jwtx.ValidateTokenWithOptions(context.Background(), tokenStr, jwks.Keyfunc, jwtx.ValidationOptions{Algorithms: []string{"PS256"}})
+		assert.Error(t, err)
+
+		// rotation keeps the key type
+		_, err = a.Rotate(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{alg}, a.Algorithms())
+	}
+}
+
+func TestRotateAlgorithm(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("issuer", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	signer, err := cert.GenerateKey(cert.KeyTypeECDSAP256)
+	assert.NoError(t, err)
+
+	_, err = a.RotateWith(context.Background(), signer)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ES256", "EdDSA"}, a.Algorithms())
+}
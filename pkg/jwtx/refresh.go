@@ -0,0 +1,244 @@
+package jwtx
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrRefreshTokenRevoked = errors.New("refresh token revoked")
+)
+
+// RefreshToken is the stored state of an opaque refresh token, the token itself is never stored, only its hash.
+type RefreshToken struct {
+	ID        string         `json:"id"`
+	Family    string         `json:"family"`
+	Subject   string         `json:"subject"`
+	Audience  []string       `json:"audience,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	IssuedAt  time.Time      `json:"issued_at"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+type RefreshStore interface {
+	Save(ctx context.Context, token *RefreshToken) error
+	// Consume marks the token as used and returns it.
+	// A token consumed more than once is returned along with ErrRefreshTokenReused,
+	// an unknown or expired token returns ErrRefreshTokenInvalid.
+	Consume(ctx context.Context, id string) (*RefreshToken, error)
+	// RevokeFamily invalidates every token of the family, the revocation is kept until expiresAt.
+	RevokeFamily(ctx context.Context, family string, expiresAt time.Time) error
+	IsFamilyRevoked(ctx context.Context, family string) (bool, error)
+}
+
+type TokenPair struct {
+	Claims           *JWTClaims
+	AccessToken      string
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// Refresher issues access tokens from the Authority along with one-time refresh tokens.
+// Every refresh rotates the refresh token within its family, replaying a used one revokes the whole family.
+type Refresher struct {
+	authority  *Authority
+	store      RefreshStore
+	expiration time.Duration
+}
+
+func NewRefresher(authority *Authority, store RefreshStore, expiration time.Duration) (*Refresher, error) {
+	if authority == nil || store == nil || expiration <= 0 {
+		return nil, errors.New("invalid authority, store or expiration")
+	}
+
+	return &Refresher{authority, store, expiration}, nil
+}
+
+func hashRefreshToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func (r *Refresher) issue(ctx context.Context, family string, subject string, audience []string, metadata map[string]any) (*TokenPair, error) {
+	claims, accessToken, err := r.authority.IssueToken(ctx, subject, audience, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	refreshToken := base64.RawURLEncoding.EncodeToString(b)
+	now := time.Now()
+	state := &RefreshToken{
+		ID:        hashRefreshToken(refreshToken),
+		Family:    family,
+		Subject:   subject,
+		Audience:  audience,
+		Metadata:  metadata,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(r.expiration),
+	}
+
+	if err := r.store.Save(ctx, state); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		Claims:           claims,
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: state.ExpiresAt,
+	}, nil
+}
+
+// IssueTokenPair starts a new token family.
+func (r *Refresher) IssueTokenPair(ctx context.Context, subject string, audience []string, metadata map[string]any) (*TokenPair, error) {
+	family, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.issue(ctx, family.String(), subject, audience, metadata)
+}
+
+// Refresh exchanges a refresh token for a new pair, the given refresh token can not be used again.
+func (r *Refresher) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	state, err := r.store.Consume(ctx, hashRefreshToken(refreshToken))
+	if errors.Is(err, ErrRefreshTokenReused) && state != nil {
+		// the family is compromised, every token derived from it has to go
+		if err := r.store.RevokeFamily(ctx, state.Family, time.Now().Add(r.expiration)); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(state.ExpiresAt) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	revoked, err := r.store.IsFamilyRevoked(ctx, state.Family)
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, ErrRefreshTokenRevoked
+	}
+
+	return r.issue(ctx, state.Family, state.Subject, state.Audience, state.Metadata)
+}
+
+// Revoke invalidates the family of the refresh token, e.g. on logout.
+func (r *Refresher) Revoke(ctx context.Context, refreshToken string) error {
+	state, err := r.store.Consume(ctx, hashRefreshToken(refreshToken))
+	if state == nil {
+		return err
+	}
+
+	return r.store.RevokeFamily(ctx, state.Family, time.Now().Add(r.expiration))
+}
+
+type refreshEntry struct {
+	token *RefreshToken
+	used  bool
+}
+
+// refreshSweepInterval bounds how often Save drops the expired entries, Consume checks the expiry anyway.
+const refreshSweepInterval = time.Minute
+
+type RefreshStoreMemory struct {
+	mu       sync.Mutex
+	tokens   map[string]*refreshEntry
+	families map[string]time.Time
+	sweptAt  time.Time
+}
+
+func NewRefreshStoreMemory() *RefreshStoreMemory {
+	return &RefreshStoreMemory{
+		tokens:   map[string]*refreshEntry{},
+		families: map[string]time.Time{},
+	}
+}
+
+func (s *RefreshStoreMemory) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < refreshSweepInterval {
+		return
+	}
+	s.sweptAt = now
+
+	for k, v := range s.tokens {
+		if now.After(v.token.ExpiresAt) {
+			delete(s.tokens, k)
+		}
+	}
+
+	for k, v := range s.families {
+		if now.After(v) {
+			delete(s.families, k)
+		}
+	}
+}
+
+func (s *RefreshStoreMemory) Save(ctx context.Context, token *RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(time.Now())
+
+	v := *token
+	s.tokens[token.ID] = &refreshEntry{token: &v}
+	return nil
+}
+
+func (s *RefreshStoreMemory) Consume(ctx context.Context, id string) (*RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.tokens[id]
+	if !ok || time.Now().After(entry.token.ExpiresAt) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	v := *entry.token
+	if entry.used {
+		return &v, ErrRefreshTokenReused
+	}
+
+	entry.used = true
+	return &v, nil
+}
+
+func (s *RefreshStoreMemory) RevokeFamily(ctx context.Context, family string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if expiresAt.After(s.families[family]) {
+		s.families[family] = expiresAt
+	}
+
+	return nil
+}
+
+func (s *RefreshStoreMemory) IsFamilyRevoked(ctx context.Context, family string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.families[family]
+	return ok && time.Now().Before(expiresAt), nil
+}
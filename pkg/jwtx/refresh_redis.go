@@ -0,0 +1,113 @@
+package jwtx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// consume returns the stored token and how many times it has been consumed, including this call.
+var scriptRefreshConsume = redis.NewScript(`
+local data = redis.call("HGET", KEYS[1], "data")
+if not data then
+	return false
+end
+local used = redis.call("HINCRBY", KEYS[1], "used", 1)
+return {data, used}
+`)
+
+type RefreshStoreRedis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRefreshStoreRedis(client redis.UniversalClient, prefix string) (*RefreshStoreRedis, error) {
+	if client == nil {
+		return nil, errors.New("invalid redis client")
+	}
+
+	if prefix == "" {
+		prefix = "jwtx:refresh"
+	}
+
+	return &RefreshStoreRedis{client, prefix}, nil
+}
+
+func (s *RefreshStoreRedis) keyToken(id string) string {
+	return s.prefix + ":token:" + id
+}
+
+func (s *RefreshStoreRedis) keyFamily(family string) string {
+	return s.prefix + ":family:" + family
+}
+
+func (s *RefreshStoreRedis) Save(ctx context.Context, token *RefreshToken) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	key := s.keyToken(token.ID)
+	_, err = s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, key, "data", data, "used", 0)
+		p.ExpireAt(ctx, key, token.ExpiresAt)
+		return nil
+	})
+	return err
+}
+
+func (s *RefreshStoreRedis) Consume(ctx context.Context, id string) (*RefreshToken, error) {
+	res, err := scriptRefreshConsume.Run(ctx, s.client, []string{s.keyToken(id)}).Slice()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res) != 2 {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	data, ok := res[0].(string)
+	if !ok {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	used, ok := res[1].(int64)
+	if !ok {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	var token RefreshToken
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return nil, err
+	}
+
+	if used > 1 {
+		return &token, ErrRefreshTokenReused
+	}
+
+	return &token, nil
+}
+
+func (s *RefreshStoreRedis) RevokeFamily(ctx context.Context, family string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return s.client.Set(ctx, s.keyFamily(family), 1, ttl).Err()
+}
+
+func (s *RefreshStoreRedis) IsFamilyRevoked(ctx context.Context, family string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.keyFamily(family)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
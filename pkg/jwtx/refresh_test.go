@@ -0,0 +1,64 @@
+package jwtx_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshRotation(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("issuer", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	r, err := jwtx.NewRefresher(a, jwtx.NewRefreshStoreMemory(), time.Hour)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	first, err := r.IssueTokenPair(ctx, "subject", []string{"audience"}, map[string]any{"foo": "bar"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, first.AccessToken)
+	assert.NotEmpty(t, first.RefreshToken)
+
+	second, err := r.Refresh(ctx, first.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.Equal(t, "subject", second.Claims.Subject)
+	assert.Equal(t, map[string]any{"foo": "bar"}, second.Claims.Metadata)
+
+	// replaying the first token revokes the family, including the token issued from it
+	_, err = r.Refresh(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, jwtx.ErrRefreshTokenReused)
+
+	_, err = r.Refresh(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, jwtx.ErrRefreshTokenRevoked)
+
+	_, err = r.Refresh(ctx, "unknown")
+	assert.ErrorIs(t, err, jwtx.ErrRefreshTokenInvalid)
+}
+
+func TestRefreshRevoke(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("issuer", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	r, err := jwtx.NewRefresher(a, jwtx.NewRefreshStoreMemory(), time.Hour)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	pair, err := r.IssueTokenPair(ctx, "subject", nil, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.Revoke(ctx, pair.RefreshToken))
+
+	_, err = r.Refresh(ctx, pair.RefreshToken)
+	assert.Error(t, err)
+}
@@ -0,0 +1,248 @@
+package jwtx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MicahParks/jwkset"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrRemoteKeySet = errors.New("unable to fetch remote key set")
+
+type RemoteKeySetOptions struct {
+	// Client defaults to a client with a 10 seconds timeout.
+	Client *http.Client
+	// RefreshInterval is the background refresh period, defaults to 5 minutes.
+	RefreshInterval time.Duration
+	// UnknownKIDInterval is the minimum delay between two refreshes triggered by an unknown kid, defaults to 1 minute.
+	UnknownKIDInterval time.Duration
+	// Lazy creates the key set even if the first fetch fails, the error is reported by Status.
+	Lazy bool
+	// OnError receives refresh failures and the keys skipped by a refresh.
+	OnError func(error)
+}
+
+type RemoteKeySetStatus struct {
+	URL         string
+	Keys        int
+	LastRefresh time.Time
+	LastAttempt time.Time
+	LastError   error
+}
+
+// Healthy reports whether the last refresh attempt succeeded.
+func (s RemoteKeySetStatus) Healthy() bool {
+	return s.LastError == nil && !s.LastRefresh.IsZero()
+}
+
+// RemoteKeySet verifies tokens against a JWKS served over HTTP, e.g. by Authority.PublicJWKS.
+type RemoteKeySet struct {
+	url     string
+	options RemoteKeySetOptions
+
+	mu     sync.RWMutex
+	keys   map[string]jwkset.JWK
+	etag   string
+	status RemoteKeySetStatus
+
+	refreshMu      sync.Mutex
+	lastUnknownKID time.Time
+}
+
+// NewRemoteKeySet fetches the key set then refreshes it in the background until ctx is done.
+func NewRemoteKeySet(ctx context.Context, url string, options RemoteKeySetOptions) (*RemoteKeySet, error) {
+	if options.Client == nil {
+		options.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	if options.RefreshInterval <= 0 {
+		options.RefreshInterval = 5 * time.Minute
+	}
+
+	if options.UnknownKIDInterval <= 0 {
+		options.UnknownKIDInterval = time.Minute
+	}
+
+	s := &RemoteKeySet{
+		url:     url,
+		options: options,
+		keys:    map[string]jwkset.JWK{},
+		status:  RemoteKeySetStatus{URL: url},
+	}
+
+	if err := s.Refresh(ctx); err != nil && !options.Lazy {
+		return nil, err
+	}
+
+	go s.run(ctx)
+	return s, nil
+}
+
+func (s *RemoteKeySet) run(ctx context.Context) {
+	ticker := time.NewTicker(s.options.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			//nolint:errcheck
+			s.Refresh(ctx)
+		}
+	}
+}
+
+// Refresh fetches the key set now.
+func (s *RemoteKeySet) Refresh(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	err := s.fetch(ctx)
+
+	s.mu.Lock()
+	s.status.LastAttempt = time.Now()
+	s.status.LastError = err
+	if err == nil {
+		s.status.LastRefresh = s.status.LastAttempt
+		s.status.Keys = len(s.keys)
+	}
+	s.mu.Unlock()
+
+	if err != nil && s.options.OnError != nil {
+		s.options.OnError(err)
+	}
+
+	return err
+}
+
+func (s *RemoteKeySet) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRemoteKeySet, err)
+	}
+
+	s.mu.RLock()
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	s.mu.RUnlock()
+
+	resp, err := s.options.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRemoteKeySet, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: unexpected status %d", ErrRemoteKeySet, resp.StatusCode)
+	}
+
+	var set jwkset.JWKSMarshal
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("%w: %w", ErrRemoteKeySet, err)
+	}
+
+	// a key we can not parse, e.g. of an unsupported type, does not invalidate the others
+	var errs []error
+	keys := make(map[string]jwkset.JWK, len(set.Keys))
+	for _, marshal := range set.Keys {
+		if marshal.USE != "" && marshal.USE != jwkset.UseSig {
+			continue
+		}
+
+		jwk, err := jwkset.NewJWKFromMarshal(marshal, jwkset.JWKMarshalOptions{}, jwkset.JWKValidateOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: key %q: %w", ErrRemoteKeySet, marshal.KID, err))
+			continue
+		}
+
+		keys[marshal.KID] = jwk
+	}
+
+	// an empty set would reject every token until the next refresh, the previous keys are kept
+	if len(keys) == 0 {
+		return errors.Join(append(errs, fmt.Errorf("%w: no signing keys", ErrRemoteKeySet))...)
+	}
+
+	if len(errs) > 0 && s.options.OnError != nil {
+		s.options.OnError(errors.Join(errs...))
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.etag = resp.Header.Get("ETag")
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *RemoteKeySet) lookup(kid string) (jwkset.JWK, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwk, ok := s.keys[kid]
+	return jwk, ok
+}
+
+// refreshUnknownKID refetches at most once per UnknownKIDInterval so forged kids can not hammer the remote,
+// requests arriving in between fail without waiting on the network.
+func (s *RemoteKeySet) refreshUnknownKID(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastUnknownKID) < s.options.UnknownKIDInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastUnknownKID = time.Now()
+	s.mu.Unlock()
+
+	//nolint:errcheck
+	s.Refresh(ctx)
+}
+
+// KeyfuncCtx returns a jwt.Keyfunc bound to ctx for unknown kid refreshes.
+func (s *RemoteKeySet) KeyfuncCtx(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		kid, ok := token.Header[jwkset.HeaderKID].(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: missing kid", ErrUnableToParse)
+		}
+
+		jwk, ok := s.lookup(kid)
+		if !ok {
+			s.refreshUnknownKID(ctx)
+			jwk, ok = s.lookup(kid)
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("%w: %w", ErrUnableToParse, jwkset.ErrKeyNotFound)
+		}
+
+		if alg := jwk.Marshal().ALG; alg != "" && alg.String() != token.Method.Alg() {
+			return nil, fmt.Errorf("%w: unexpected algorithm %s", ErrUnableToParse, token.Method.Alg())
+		}
+
+		return jwk.Key(), nil
+	}
+}
+
+func (s *RemoteKeySet) Keyfunc(token *jwt.Token) (any, error) {
+	return s.KeyfuncCtx(context.Background())(token)
+}
+
+func (s *RemoteKeySet) Status() RemoteKeySetStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.status
+}
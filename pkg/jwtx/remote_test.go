@@ -0,0 +1,179 @@
+package jwtx_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteKeySet(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("issuer", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		b, err := a.PublicJWKS(r.Context())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		//nolint:errcheck
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	keys, err := jwtx.NewRemoteKeySet(ctx, srv.URL, jwtx.RemoteKeySetOptions{RefreshInterval: time.Hour, UnknownKIDInterval: time.Hour})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, hits.Load())
+
+	status := keys.Status()
+	assert.True(t, status.Healthy())
+	assert.Equal(t, 1, status.Keys)
+
+	_, tokenStr, err := a.IssueToken(ctx, "subject", nil, nil)
+	assert.NoError(t, err)
+
+	_, _, err = jwtx.ValidateToken(ctx, tokenStr, keys.Keyfunc)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, hits.Load())
+
+	// an unknown kid triggers a single refetch
+	_, err = a.Rotate(ctx)
+	assert.NoError(t, err)
+
+	_, tokenStr, err = a.IssueToken(ctx, "subject", nil, nil)
+	assert.NoError(t, err)
+
+	_, _, err = jwtx.ValidateToken(ctx, tokenStr, keys.Keyfunc)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, hits.Load())
+	assert.Equal(t, 2, keys.Status().Keys)
+
+	// further unknown kids are rate limited
+	_, err = a.Rotate(ctx)
+	assert.NoError(t, err)
+
+	_, tokenStr, err = a.IssueToken(ctx, "subject", nil, nil)
+	assert.NoError(t, err)
+
+	_, _, err = jwtx.ValidateToken(ctx, tokenStr, keys.Keyfunc)
+	assert.Error(t, err)
+	assert.EqualValues(t, 2, hits.Load())
+}
+
+func TestRemoteKeySetUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := jwtx.NewRemoteKeySet(ctx, srv.URL, jwtx.RemoteKeySetOptions{})
+	assert.ErrorIs(t, err, jwtx.ErrRemoteKeySet)
+
+	keys, err := jwtx.NewRemoteKeySet(ctx, srv.URL, jwtx.RemoteKeySetOptions{Lazy: true})
+	assert.NoError(t, err)
+	assert.False(t, keys.Status().Healthy())
+	assert.ErrorIs(t, keys.Status().LastError, jwtx.ErrRemoteKeySet)
+}
+
+func TestRemoteKeySetSkipsInvalidKeys(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("issuer", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := a.PublicJWKS(r.Context())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var set map[string][]any
+		//nolint:errcheck
+		json.Unmarshal(b, &set)
+		set["keys"] = append(set["keys"], map[string]any{"kty": "OKP", "crv": "Ed25519", "kid": "broken", "x": "AAAA"})
+
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(set)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var skipped atomic.Int32
+	keys, err := jwtx.NewRemoteKeySet(ctx, srv.URL, jwtx.RemoteKeySetOptions{OnError: func(error) { skipped.Add(1) }})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, skipped.Load())
+	assert.Equal(t, 1, keys.Status().Keys)
+
+	_, tokenStr, err := a.IssueToken(ctx, "subject", nil, nil)
+	assert.NoError(t, err)
+
+	_, _, err = jwtx.ValidateToken(ctx, tokenStr, keys.Keyfunc)
+	assert.NoError(t, err)
+}
+
+func TestRemoteKeySetKeepsKeysOnEmptySet(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	a, err := jwtx.NewAuthority("issuer", time.Minute, pub, priv)
+	assert.NoError(t, err)
+
+	var empty atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if empty.Load() {
+			//nolint:errcheck
+			w.Write([]byte(`{"keys":[]}`))
+			return
+		}
+
+		b, err := a.PublicJWKS(r.Context())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		//nolint:errcheck
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	keys, err := jwtx.NewRemoteKeySet(ctx, srv.URL, jwtx.RemoteKeySetOptions{RefreshInterval: time.Hour})
+	assert.NoError(t, err)
+
+	empty.Store(true)
+	assert.ErrorIs(t, keys.Refresh(ctx), jwtx.ErrRemoteKeySet)
+	assert.Equal(t, 1, keys.Status().Keys)
+
+	_, tokenStr, err := a.IssueToken(ctx, "subject", nil, nil)
+	assert.NoError(t, err)
+
+	_, _, err = jwtx.ValidateToken(ctx, tokenStr, keys.Keyfunc)
+	assert.NoError(t, err, "the previous keys are kept")
+}
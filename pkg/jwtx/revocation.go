@@ -0,0 +1,116 @@
+package jwtx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrTokenRevoked = errors.New("token revoked")
+
+type RevocationStore interface {
+	// Revoke denies the token with the given id, the entry is kept until expiresAt.
+	Revoke(ctx context.Context, id string, expiresAt time.Time) error
+	// RevokeSubject denies every token of the subject issued up to before, the entry is kept until expiresAt.
+	RevokeSubject(ctx context.Context, subject string, before time.Time, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, claims *JWTClaims) (bool, error)
+}
+
+// RevokeToken denies the token until it would have expired anyway.
+func RevokeToken(ctx context.Context, store RevocationStore, claims *JWTClaims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return ErrInvalidClaims
+	}
+
+	return store.Revoke(ctx, claims.ID, claims.ExpiresAt.Time)
+}
+
+// issuedBefore compares at the second precision of the iat claim, a token without iat is considered revoked.
+func issuedBefore(claims *JWTClaims, before time.Time) bool {
+	if claims.IssuedAt == nil {
+		return true
+	}
+
+	return claims.IssuedAt.Unix() <= before.Unix()
+}
+
+type revokedSubject struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
+type RevocationStoreMemory struct {
+	mu       sync.RWMutex
+	ids      map[string]time.Time
+	subjects map[string]revokedSubject
+}
+
+func NewRevocationStoreMemory() *RevocationStoreMemory {
+	return &RevocationStoreMemory{
+		ids:      map[string]time.Time{},
+		subjects: map[string]revokedSubject{},
+	}
+}
+
+func (s *RevocationStoreMemory) sweep(now time.Time) {
+	for k, v := range s.ids {
+		if now.After(v) {
+			delete(s.ids, k)
+		}
+	}
+
+	for k, v := range s.subjects {
+		if now.After(v.expiresAt) {
+			delete(s.subjects, k)
+		}
+	}
+}
+
+func (s *RevocationStoreMemory) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(time.Now())
+	if current, ok := s.ids[id]; ok && current.After(expiresAt) {
+		return nil
+	}
+
+	s.ids[id] = expiresAt
+	return nil
+}
+
+func (s *RevocationStoreMemory) RevokeSubject(ctx context.Context, subject string, before time.Time, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(time.Now())
+
+	current, ok := s.subjects[subject]
+	if ok && current.before.After(before) {
+		before = current.before
+	}
+
+	if ok && current.expiresAt.After(expiresAt) {
+		expiresAt = current.expiresAt
+	}
+
+	s.subjects[subject] = revokedSubject{before, expiresAt}
+	return nil
+}
+
+func (s *RevocationStoreMemory) IsRevoked(ctx context.Context, claims *JWTClaims) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	if expiresAt, ok := s.ids[claims.ID]; ok && now.Before(expiresAt) {
+		return true, nil
+	}
+
+	if v, ok := s.subjects[claims.Subject]; ok && now.Before(v.expiresAt) {
+		return issuedBefore(claims, v.before), nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,86 @@
+package jwtx
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// revokeMax keeps the greatest value and ttl of the key, a later revocation never weakens an earlier one.
+var revokeMax = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]) or ARGV[1])
+local value = math.max(current, tonumber(ARGV[1]))
+local ttl = math.max(redis.call("PTTL", KEYS[1]), tonumber(ARGV[2]))
+
+redis.call("SET", KEYS[1], string.format("%d", value), "PX", ttl)
+return 1
+`)
+
+type RevocationStoreRedis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRevocationStoreRedis(client redis.UniversalClient, prefix string) (*RevocationStoreRedis, error) {
+	if client == nil {
+		return nil, errors.New("invalid redis client")
+	}
+
+	if prefix == "" {
+		prefix = "jwtx:revocation"
+	}
+
+	return &RevocationStoreRedis{client, prefix}, nil
+}
+
+func (s *RevocationStoreRedis) keyID(id string) string {
+	return s.prefix + ":jti:" + id
+}
+
+func (s *RevocationStoreRedis) keySubject(subject string) string {
+	return s.prefix + ":sub:" + subject
+}
+
+func (s *RevocationStoreRedis) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return revokeMax.Run(ctx, s.client, []string{s.keyID(id)}, 1, ttl.Milliseconds()).Err()
+}
+
+func (s *RevocationStoreRedis) RevokeSubject(ctx context.Context, subject string, before time.Time, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return revokeMax.Run(ctx, s.client, []string{s.keySubject(subject)}, before.Unix(), ttl.Milliseconds()).Err()
+}
+
+func (s *RevocationStoreRedis) IsRevoked(ctx context.Context, claims *JWTClaims) (bool, error) {
+	vals, err := s.client.MGet(ctx, s.keyID(claims.ID), s.keySubject(claims.Subject)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	if vals[0] != nil {
+		return true, nil
+	}
+
+	v, ok := vals[1].(string)
+	if !ok {
+		return false, nil
+	}
+
+	before, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return false, err
+	}
+
+	return issuedBefore(claims, time.Unix(before, 0)), nil
+}
@@ -0,0 +1,72 @@
+package jwtx_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hiendaovinh/toolkit/pkg/db"
+	"github.com/hiendaovinh/toolkit/pkg/jwtx"
+	"github.com/stretchr/testify/assert"
+)
+
+// testRevocationStore checks the semantics shared by the RevocationStore implementations.
+func testRevocationStore(t *testing.T, store jwtx.RevocationStore) {
+	ctx := context.Background()
+	now := time.Now()
+	claims := func(id string, sub string, iat time.Time) *jwtx.JWTClaims {
+		return &jwtx.JWTClaims{RegisteredClaims: jwt.RegisteredClaims{ID: id, Subject: sub, IssuedAt: jwt.NewNumericDate(iat)}}
+	}
+
+	assert.NoError(t, store.Revoke(ctx, "a", now.Add(time.Minute)))
+	assert.NoError(t, store.Revoke(ctx, "a", now.Add(50*time.Millisecond)), "a shorter revocation does not shorten the first one")
+	assert.NoError(t, store.Revoke(ctx, "b", now.Add(-time.Second)), "expired tokens are ignored")
+
+	assert.NoError(t, store.RevokeSubject(ctx, "foo", now, now.Add(time.Minute)))
+	assert.NoError(t, store.RevokeSubject(ctx, "foo", now.Add(-time.Hour), now.Add(50*time.Millisecond)), "an earlier revocation does not weaken the first one")
+
+	time.Sleep(100 * time.Millisecond)
+
+	revoked, err := store.IsRevoked(ctx, claims("a", "bar", now))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, claims("b", "bar", now))
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, claims("c", "foo", now.Add(-time.Minute)))
+	assert.NoError(t, err)
+	assert.True(t, revoked, "issued before the subject revocation")
+
+	revoked, err = store.IsRevoked(ctx, claims("c", "foo", now.Add(time.Minute)))
+	assert.NoError(t, err)
+	assert.False(t, revoked, "issued after the subject revocation")
+}
+
+func TestRevocationStoreMemory(t *testing.T) {
+	testRevocationStore(t, jwtx.NewRevocationStoreMemory())
+}
+
+func TestRevocationStoreRedis(t *testing.T) {
+	url := os.Getenv("TOOLKIT_TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TOOLKIT_TEST_REDIS_URL is not set")
+	}
+
+	client, err := db.InitRedis(&db.RedisConfig{URL: url})
+	assert.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	prefix := "test:" + t.Name()
+	store, err := jwtx.NewRevocationStoreRedis(client, prefix)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		client.Del(context.Background(), prefix+":jti:a", prefix+":sub:foo")
+	})
+
+	testRevocationStore(t, store)
+}
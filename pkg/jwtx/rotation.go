@@ -0,0 +1,121 @@
+package jwtx
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"time"
+
+	"github.com/hiendaovinh/toolkit/pkg/cert"
+)
+
+// RotationPolicy drives Authority.RunRotation.
+type RotationPolicy struct {
+	// Interval is the lifetime of a signing key before a new one is promoted.
+	Interval time.Duration
+	// Retention is how long a retired key stays published, defaults to 2x the token expiration
+	// so every token signed right before the rotation can still be verified.
+	Retention time.Duration
+	// CheckEvery is the polling period, defaults to a tenth of Interval capped at a minute.
+	CheckEvery time.Duration
+	// Generate creates the next key, defaults to a key of the same type as the active one.
+	Generate func() (crypto.Signer, error)
+	// OnRotate persists the next key before it is promoted, the rotation is aborted when it fails.
+	// Without it the rotated keys only live in memory and a restart invalidates the tokens they signed.
+	OnRotate func(ctx context.Context, id string, signer crypto.Signer) error
+	// OnError receives rotation failures, the loop keeps going and retries on the next tick.
+	OnError func(error)
+}
+
+func (p RotationPolicy) retention(expiration time.Duration) time.Duration {
+	if p.Retention > 0 {
+		return p.Retention
+	}
+
+	return 2 * expiration
+}
+
+func (p RotationPolicy) checkEvery() time.Duration {
+	if p.CheckEvery > 0 {
+		return p.CheckEvery
+	}
+
+	every := p.Interval / 10
+	if every > time.Minute {
+		every = time.Minute
+	}
+
+	if every <= 0 {
+		every = time.Second
+	}
+
+	return every
+}
+
+// RotateIfDue rotates the signing key when it is older than the policy interval
+// and prunes retired keys past their retention.
+func (g *Authority) RotateIfDue(ctx context.Context, policy RotationPolicy) (bool, error) {
+	if policy.Interval <= 0 {
+		return false, errors.New("invalid rotation interval")
+	}
+
+	g.Prune(policy.retention(g.expiration))
+
+	g.mu.RLock()
+	createdAt := g.active.createdAt
+	g.mu.RUnlock()
+
+	if time.Since(createdAt) < policy.Interval {
+		return false, nil
+	}
+
+	generate := policy.Generate
+	if generate == nil {
+		keyType := g.activeKey().keyType
+		generate = func() (crypto.Signer, error) { return cert.GenerateKey(keyType) }
+	}
+
+	signer, err := generate()
+	if err != nil {
+		return false, err
+	}
+
+	if policy.OnRotate != nil {
+		id, err := cert.KeyID(signer.Public())
+		if err != nil {
+			return false, err
+		}
+
+		if err := policy.OnRotate(ctx, id, signer); err != nil {
+			return false, err
+		}
+	}
+
+	_, err = g.RotateWith(ctx, signer)
+	return err == nil, err
+}
+
+// RunRotation blocks and applies the policy until ctx is done.
+// Run it on a single instance, each replica rotating on its own would publish a different key set.
+// Restarts and other replicas load the keys persisted by OnRotate and restore their age with SetCreatedAt.
+func (g *Authority) RunRotation(ctx context.Context, policy RotationPolicy) error {
+	if policy.Interval <= 0 {
+		return errors.New("invalid rotation interval")
+	}
+
+	ticker := time.NewTicker(policy.checkEvery())
+	defer ticker.Stop()
+
+	for {
+		_, err := g.RotateIfDue(ctx, policy)
+		if err != nil && policy.OnError != nil {
+			policy.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -3,12 +3,103 @@ package jwtx
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// ValidationOptions narrows which tokens are accepted on top of the signature check.
+// The zero value only pins the algorithm to EdDSA.
+type ValidationOptions struct {
+	// Issuer is the expected iss claim, empty accepts any issuer.
+	Issuer string
+	// Audiences are the accepted audiences, the token has to carry at least one of them.
+	Audiences []string
+	// Leeway is the clock skew tolerated for exp, nbf and iat.
+	Leeway time.Duration
+	// RequiredClaims lists registered claims that must be present: iss, sub, aud, exp, nbf, iat or jti.
+	RequiredClaims []string
+	// Algorithms are the accepted JWS algorithms, defaults to EdDSA.
+	// Set it to Authority.Algorithms() to accept the RSA and ECDSA keys of an Authority.
+	Algorithms []string
+}
+
+func (opts ValidationOptions) algorithms() []string {
+	if len(opts.Algorithms) > 0 {
+		return opts.Algorithms
+	}
+
+	return []string{jwt.SigningMethodEdDSA.Alg()}
+}
+
+func (opts ValidationOptions) parserOptions() []jwt.ParserOption {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(opts.algorithms()),
+		jwt.WithIssuedAt(),
+	}
+
+	if opts.Issuer != "" {
+		options = append(options, jwt.WithIssuer(opts.Issuer))
+	}
+
+	if opts.Leeway > 0 {
+		options = append(options, jwt.WithLeeway(opts.Leeway))
+	}
+
+	if slices.Contains(opts.RequiredClaims, "exp") {
+		options = append(options, jwt.WithExpirationRequired())
+	}
+
+	return options
+}
+
+func (opts ValidationOptions) validate(claims *JWTClaims) error {
+	for _, name := range opts.RequiredClaims {
+		var missing bool
+		switch name {
+		case "iss":
+			missing = claims.Issuer == ""
+		case "sub":
+			missing = claims.Subject == ""
+		case "aud":
+			missing = len(claims.Audience) == 0
+		case "exp":
+			missing = claims.ExpiresAt == nil
+		case "nbf":
+			missing = claims.NotBefore == nil
+		case "iat":
+			missing = claims.IssuedAt == nil
+		case "jti":
+			missing = claims.ID == ""
+		default:
+			return fmt.Errorf("%w: unsupported required claim %s", ErrInvalidClaims, name)
+		}
+
+		if missing {
+			return fmt.Errorf("%w: missing %s", ErrInvalidClaims, name)
+		}
+	}
+
+	if len(opts.Audiences) == 0 {
+		return nil
+	}
+
+	for _, aud := range claims.Audience {
+		if slices.Contains(opts.Audiences, aud) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: unexpected audience", ErrInvalidClaims)
+}
+
 func ValidateToken(ctx context.Context, tokenStr string, fnc jwt.Keyfunc) (*jwt.Token, *JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &JWTClaims{}, fnc)
+	return ValidateTokenWithOptions(ctx, tokenStr, fnc, ValidationOptions{})
+}
+
+func ValidateTokenWithOptions(ctx context.Context, tokenStr string, fnc jwt.Keyfunc, opts ValidationOptions) (*jwt.Token, *JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &JWTClaims{}, fnc, opts.parserOptions()...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("%w: %q", ErrUnableToParse, err)
 	}
@@ -22,5 +113,9 @@ func ValidateToken(ctx context.Context, tokenStr string, fnc jwt.Keyfunc) (*jwt.
 		return nil, nil, ErrInvalidClaims
 	}
 
+	if err := opts.validate(claims); err != nil {
+		return nil, nil, err
+	}
+
 	return token, claims, nil
 }
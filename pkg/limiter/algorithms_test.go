@@ -0,0 +1,194 @@
+package limiter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hiendaovinh/toolkit/pkg/limiter"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlgorithmsInvalid(t *testing.T) {
+	// limits are checked before reaching Redis
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	t.Cleanup(func() { rdb.Close() })
+	ctx := context.Background()
+
+	sliding, err := limiter.NewSlidingWindowRedis(rdb)
+	assert.Nil(t, err, "should be successful")
+	for _, limit := range []limiter.Window{{Limit: 0, Window: time.Second}, {Limit: 1, Window: 0}} {
+		_, err = sliding.Allow(ctx, "key", limit)
+		assert.ErrorIs(t, err, limiter.ErrInvalidLimit)
+	}
+	_, err = sliding.AllowN(ctx, "key", limiter.Window{Limit: 1, Window: time.Second}, -1)
+	assert.ErrorIs(t, err, limiter.ErrInvalidLimit)
+
+	concurrency, err := limiter.NewConcurrencyRedis(rdb)
+	assert.Nil(t, err, "should be successful")
+	_, err = concurrency.Acquire(ctx, "key", 0, time.Second)
+	assert.ErrorIs(t, err, limiter.ErrInvalidLimit)
+	_, err = concurrency.Acquire(ctx, "key", 1, 0)
+	assert.ErrorIs(t, err, limiter.ErrInvalidLimit)
+
+	quota, err := limiter.NewQuotaRedis(rdb)
+	assert.Nil(t, err, "should be successful")
+	_, err = quota.Allow(ctx, "key", limiter.Quota{})
+	assert.ErrorIs(t, err, limiter.ErrInvalidLimit)
+	_, err = quota.AllowN(ctx, "key", limiter.Quota{Limit: 1}, -1)
+	assert.ErrorIs(t, err, limiter.ErrInvalidLimit)
+}
+
+func TestSlidingWindowRedis(t *testing.T) {
+	l, err := limiter.NewSlidingWindowRedis(testRedis(t))
+	assert.Nil(t, err, "should be successful")
+
+	ctx := context.Background()
+	key := "test:" + t.Name()
+	t.Cleanup(func() { l.Reset(ctx, key) })
+
+	limit := limiter.Window{Limit: 3, Window: 200 * time.Millisecond}
+	usage, err := l.AllowN(ctx, key, limit, 2)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, limiter.Usage{Limit: 3, Used: 2, Remaining: 1, ResetAfter: limit.Window}, *usage)
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = l.Allow(ctx, key, limit)
+	assert.Nil(t, err, "should be successful")
+
+	usage, err = l.Allow(ctx, key, limit)
+	assert.ErrorIs(t, err, limiter.ErrRateLimited)
+	assert.Equal(t, 3, usage.Used)
+
+	// the first two events leave the window first
+	d, _ := limiter.RetryAfter(err)
+	assert.Greater(t, d, time.Duration(0))
+	assert.LessOrEqual(t, d, 100*time.Millisecond)
+
+	_, err = l.AllowN(ctx, key, limit, 4)
+	assert.ErrorIs(t, err, limiter.ErrRateLimited, "more than the limit never fits")
+
+	_, err = l.Allow(limiter.Skip(ctx), key, limit)
+	assert.Nil(t, err, "skipped contexts are not limited")
+
+	time.Sleep(d)
+	usage, err = l.AllowN(ctx, key, limit, 2)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, 3, usage.Used)
+}
+
+func TestConcurrencyRedis(t *testing.T) {
+	l, err := limiter.NewConcurrencyRedis(testRedis(t))
+	assert.Nil(t, err, "should be successful")
+
+	ctx := context.Background()
+	key := "test:" + t.Name()
+
+	first, err := l.Acquire(ctx, key, 2, time.Minute)
+	assert.Nil(t, err, "should be successful")
+	t.Cleanup(func() { l.Release(ctx, first) })
+
+	short, err := l.Acquire(ctx, key, 2, 100*time.Millisecond)
+	assert.Nil(t, err, "should be successful")
+
+	_, err = l.Acquire(ctx, key, 2, time.Minute)
+	assert.ErrorIs(t, err, limiter.ErrRateLimited)
+	d, _ := limiter.RetryAfter(err)
+	assert.LessOrEqual(t, d, 100*time.Millisecond, "retry once the shortest lease expires")
+
+	n, err := l.InFlight(ctx, key)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, 2, n)
+
+	skipped, err := l.Acquire(limiter.Skip(ctx), key, 2, time.Minute)
+	assert.Nil(t, err, "skipped contexts are not limited")
+	assert.Nil(t, l.Release(ctx, skipped))
+
+	// an expired lease frees its slot and can not be extended
+	time.Sleep(150 * time.Millisecond)
+	assert.ErrorIs(t, l.Extend(ctx, short, time.Minute), limiter.ErrLeaseExpired)
+
+	second, err := l.Acquire(ctx, key, 2, time.Minute)
+	assert.Nil(t, err, "should be successful")
+	assert.Nil(t, l.Extend(ctx, second, time.Minute))
+
+	assert.Nil(t, l.Release(ctx, second))
+	n, err = l.InFlight(ctx, key)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, 1, n)
+}
+
+func TestQuotaRedis(t *testing.T) {
+	l, err := limiter.NewQuotaRedis(testRedis(t))
+	assert.Nil(t, err, "should be successful")
+
+	ctx := context.Background()
+	key := "test:" + t.Name()
+	quota := limiter.Quota{Limit: 10, Period: limiter.QuotaMonthly}
+	t.Cleanup(func() { l.Reset(ctx, key, quota) })
+
+	usage, err := l.AllowN(ctx, key, quota, 7)
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, 3, usage.Remaining)
+	assert.LessOrEqual(t, usage.ResetAfter, 31*24*time.Hour)
+
+	usage, err = l.AllowN(ctx, key, quota, 4)
+	assert.ErrorIs(t, err, limiter.ErrRateLimited)
+	assert.Equal(t, 7, usage.Used, "denied events are not counted")
+
+	d, _ := limiter.RetryAfter(err)
+	assert.Equal(t, usage.ResetAfter, d, "retry at the next period")
+
+	_, err = l.AllowN(ctx, key, quota, 3)
+	assert.Nil(t, err, "should be successful")
+
+	usage, err = l.UsageAt(ctx, key, quota, time.Now())
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, 10, usage.Used)
+
+	usage, err = l.UsageAt(ctx, key, quota, time.Now().AddDate(0, -1, 0))
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, 0, usage.Used)
+
+	_, err = l.Allow(limiter.Skip(ctx), key, quota)
+	assert.Nil(t, err, "skipped contexts are not limited")
+
+	assert.Nil(t, l.Reset(ctx, key, quota))
+	_, err = l.Allow(ctx, key, quota)
+	assert.Nil(t, err, "should be successful")
+}
+
+func TestQuotaRedisPeriods(t *testing.T) {
+	l, err := limiter.NewQuotaRedis(testRedis(t))
+	assert.Nil(t, err, "should be successful")
+
+	ctx := context.Background()
+	key := "test:" + t.Name()
+	daily := limiter.Quota{Limit: 2, Period: limiter.QuotaDaily}
+	monthly := limiter.Quota{Limit: 5, Period: limiter.QuotaMonthly}
+	t.Cleanup(func() {
+		l.Reset(ctx, key, daily)
+		l.Reset(ctx, key, monthly)
+	})
+
+	// both periods start on the same date on the first of a month, their counters must not be shared
+	for range 2 {
+		_, err = l.Allow(ctx, key, daily)
+		assert.Nil(t, err, "should be successful")
+
+		_, err = l.Allow(ctx, key, monthly)
+		assert.Nil(t, err, "should be successful")
+	}
+
+	_, err = l.Allow(ctx, key, daily)
+	assert.ErrorIs(t, err, limiter.ErrRateLimited)
+
+	usage, err := l.Allow(ctx, key, monthly)
+	assert.Nil(t, err, "the daily quota is counted apart")
+	assert.Equal(t, 3, usage.Used)
+
+	usage, err = l.UsageAt(ctx, key, daily, time.Now())
+	assert.Nil(t, err, "should be successful")
+	assert.Equal(t, 2, usage.Used)
+}
@@ -0,0 +1,299 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis_rate/v10"
+)
+
+// FailureMode is how LimiterBreaker answers while its limiter is failing.
+type FailureMode uint8
+
+const (
+	// FailOpen allows the requests, they are not limited until the limiter recovers.
+	FailOpen FailureMode = iota
+	// FailClosed denies the requests with a RateLimitedError retrying once the breaker probes again.
+	FailClosed
+	// FailFallback asks LimiterBreakerOptions.Fallback, e.g. a LimiterMemory limiting per instance.
+	FailFallback
+)
+
+func (m FailureMode) String() string {
+	switch m {
+	case FailClosed:
+		return "fail-closed"
+	case FailFallback:
+		return "fail-fallback"
+	}
+
+	return "fail-open"
+}
+
+// ErrBreakerOpen is reported for the requests an open breaker keeps from the limiter.
+var ErrBreakerOpen = errors.New("limiter breaker open")
+
+type BreakerState uint8
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe through to the limiter.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+
+	return "closed"
+}
+
+type LimiterBreakerOptions struct {
+	Mode     FailureMode
+	Fallback Limiter
+	// FailureThreshold is the number of consecutive failures opening the breaker, defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is the time before probing an open breaker, defaults to 10 seconds.
+	OpenTimeout time.Duration
+	// OnError receives the failures of the limiter, and ErrBreakerOpen for the requests answered while open.
+	OnError func(error)
+	// Logger reports the state changes of the breaker, defaults to slog.Default().
+	// The requests answered by the Mode are counted in Stats instead.
+	Logger *slog.Logger
+}
+
+// BreakerStats are counters since the creation of the LimiterBreaker.
+type BreakerStats struct {
+	State BreakerState
+	// Failures of the limiter, rate limited requests are not failures.
+	Failures uint64
+	// Trips is the number of times the breaker opened.
+	Trips uint64
+	// Degraded is the number of requests answered by the FailureMode.
+	Degraded uint64
+}
+
+// LimiterBreaker guards a limiter depending on a remote store, e.g. LimiterRedis during a Redis outage.
+// It only wraps the GCRA Limiter, SlidingWindowRedis, ConcurrencyRedis and QuotaRedis return the errors of Redis as is.
+type LimiterBreaker struct {
+	limiter Limiter
+	opts    LimiterBreakerOptions
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probedAt time.Time
+
+	stats struct {
+		failures atomic.Uint64
+		trips    atomic.Uint64
+		degraded atomic.Uint64
+	}
+}
+
+func NewLimiterBreaker(limiter Limiter, opts LimiterBreakerOptions) (*LimiterBreaker, error) {
+	if limiter == nil {
+		return nil, errors.New("invalid limiter")
+	}
+
+	if opts.Mode == FailFallback && opts.Fallback == nil {
+		return nil, errors.New("invalid fallback")
+	}
+
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 5
+	}
+
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = 10 * time.Second
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	return &LimiterBreaker{limiter: limiter, opts: opts}, nil
+}
+
+func (l *LimiterBreaker) Stats() BreakerStats {
+	l.mu.Lock()
+	state := l.state
+	l.mu.Unlock()
+
+	return BreakerStats{
+		State:    state,
+		Failures: l.stats.failures.Load(),
+		Trips:    l.stats.trips.Load(),
+		Degraded: l.stats.degraded.Load(),
+	}
+}
+
+func (l *LimiterBreaker) Allow(ctx context.Context, key string, limit redis_rate.Limit) error {
+	_, err := l.AllowN(ctx, key, limit, 1)
+	return err
+}
+
+func (l *LimiterBreaker) AllowResult(ctx context.Context, key string, limit redis_rate.Limit) (*redis_rate.Result, error) {
+	return l.AllowN(ctx, key, limit, 1)
+}
+
+func (l *LimiterBreaker) AllowN(ctx context.Context, key string, limit redis_rate.Limit, n int) (*redis_rate.Result, error) {
+	if Skipped(ctx) {
+		return skipped(limit, n), nil
+	}
+
+	probe, ok := l.acquire(ctx)
+	if !ok {
+		return l.degrade(ctx, key, limit, n, ErrBreakerOpen)
+	}
+
+	res, err := l.limiter.AllowN(ctx, key, limit, n)
+	switch {
+	case err == nil, errors.Is(err, ErrRateLimited):
+		l.succeed(ctx, probe)
+		return res, err
+	case ctx.Err() != nil, errors.Is(err, ErrInvalidLimit):
+		// the caller gave up or misconfigured the limit, it says nothing about the limiter
+		l.release(ctx, probe, err)
+		return nil, err
+	}
+
+	l.fail(ctx, probe, err)
+	return l.degrade(ctx, key, limit, n, err)
+}
+
+// Reset resets the fallback as well, the error is the one of the limiter.
+func (l *LimiterBreaker) Reset(ctx context.Context, key string) error {
+	if l.opts.Fallback != nil {
+		//nolint:errcheck
+		l.opts.Fallback.Reset(ctx, key)
+	}
+
+	return l.limiter.Reset(ctx, key)
+}
+
+// acquire reports whether the request may use the limiter, probe is set for the probe of a half-open breaker.
+func (l *LimiterBreaker) acquire(ctx context.Context) (bool, bool) {
+	l.mu.Lock()
+	switch l.state {
+	case BreakerOpen:
+		if time.Since(l.openedAt) < l.opts.OpenTimeout {
+			l.mu.Unlock()
+			return false, false
+		}
+
+		l.state = BreakerHalfOpen
+		l.probedAt = time.Now()
+		l.mu.Unlock()
+
+		l.transition(ctx, BreakerOpen, BreakerHalfOpen, nil)
+		return true, true
+	case BreakerHalfOpen:
+		l.mu.Unlock()
+		return false, false
+	}
+
+	l.mu.Unlock()
+	return false, true
+}
+
+// succeed closes the breaker on a successful probe, the requests started before it opened leave it as is.
+func (l *LimiterBreaker) succeed(ctx context.Context, probe bool) {
+	l.mu.Lock()
+	from := l.state
+	if probe || l.state == BreakerClosed {
+		l.state = BreakerClosed
+		l.failures = 0
+	}
+	l.mu.Unlock()
+
+	if probe && from != BreakerClosed {
+		l.transition(ctx, from, BreakerClosed, nil)
+	}
+}
+
+func (l *LimiterBreaker) fail(ctx context.Context, probe bool, err error) {
+	l.stats.failures.Add(1)
+
+	l.mu.Lock()
+	from := l.state
+	l.failures++
+	trip := probe || (l.state == BreakerClosed && l.failures >= l.opts.FailureThreshold)
+	if trip {
+		l.state = BreakerOpen
+		l.openedAt = time.Now()
+		l.stats.trips.Add(1)
+	}
+	l.mu.Unlock()
+
+	if trip {
+		l.transition(ctx, from, BreakerOpen, err)
+	}
+}
+
+// transition logs a change of state, opening is a warning carrying the failure of the limiter.
+func (l *LimiterBreaker) transition(ctx context.Context, from, to BreakerState, err error) {
+	level := slog.LevelInfo
+	args := []any{"from", from.String(), "to", to.String(), "mode", l.opts.Mode.String()}
+	if to == BreakerOpen {
+		level = slog.LevelWarn
+		args = append(args, "error", err)
+	}
+
+	l.opts.Logger.Log(ctx, level, "rate limiter breaker state changed", args...)
+}
+
+// release gives the probe back when it was not conclusive.
+func (l *LimiterBreaker) release(ctx context.Context, probe bool, err error) {
+	if !probe {
+		return
+	}
+
+	l.mu.Lock()
+	l.state = BreakerOpen
+	l.mu.Unlock()
+
+	l.transition(ctx, BreakerHalfOpen, BreakerOpen, err)
+}
+
+// retryAfter is the time before the next probe, a failing probe in flight opens the breaker for OpenTimeout.
+func (l *LimiterBreaker) retryAfter() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch l.state {
+	case BreakerOpen:
+		return max(l.opts.OpenTimeout-time.Since(l.openedAt), 0)
+	case BreakerHalfOpen:
+		return max(l.opts.OpenTimeout-time.Since(l.probedAt), 0)
+	}
+
+	return 0
+}
+
+func (l *LimiterBreaker) degrade(ctx context.Context, key string, limit redis_rate.Limit, n int, err error) (*redis_rate.Result, error) {
+	l.stats.degraded.Add(1)
+	if l.opts.OnError != nil {
+		l.opts.OnError(err)
+	}
+
+	switch l.opts.Mode {
+	case FailClosed:
+		retryAfter := l.retryAfter()
+		return &redis_rate.Result{Limit: limit, RetryAfter: retryAfter}, &RateLimitedError{RetryAfter: retryAfter}
+	case FailFallback:
+		return l.opts.Fallback.AllowN(ctx, key, limit, n)
+	}
+
+	return skipped(limit, n), nil
+}
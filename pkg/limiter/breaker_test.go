@@ -0,0 +1,186 @@
+package limiter_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis_rate/v10"
+	"github.com/hiendaovinh/toolkit/pkg/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+// limiterFlaky fails while down, allowing everything otherwise.
+type limiterFlaky struct {
+	down  atomic.Bool
+	calls atomic.Int64
+	gate  atomic.Pointer[flakyGate]
+}
+
+type flakyGate struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+// hold blocks the next call until release is closed, entered is closed once the call is blocked.
+func (l *limiterFlaky) hold() (chan struct{}, chan struct{}) {
+	gate := &flakyGate{entered: make(chan struct{}), release: make(chan struct{})}
+	l.gate.Store(gate)
+	return gate.entered, gate.release
+}
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func (l *limiterFlaky) Allow(ctx context.Context, key string, limit redis_rate.Limit) error {
+	_, err := l.AllowN(ctx, key, limit, 1)
+	return err
+}
+
+func (l *limiterFlaky) AllowResult(ctx context.Context, key string, limit redis_rate.Limit) (*redis_rate.Result, error) {
+	return l.AllowN(ctx, key, limit, 1)
+}
+
+func (l *limiterFlaky) AllowN(ctx context.Context, key string, limit redis_rate.Limit, n int) (*redis_rate.Result, error) {
+	l.calls.Add(1)
+	if gate := l.gate.Swap(nil); gate != nil {
+		close(gate.entered)
+		<-gate.release
+	}
+
+	if l.down.Load() {
+		return nil, errors.New("connection refused")
+	}
+
+	return &redis_rate.Result{Limit: limit, Allowed: n, Remaining: limit.Burst - n, RetryAfter: -1}, nil
+}
+
+func (l *limiterFlaky) Reset(ctx context.Context, key string) error {
+	return nil
+}
+
+func TestLimiterBreaker(t *testing.T) {
+	ctx := context.Background()
+	limit := redis_rate.PerMinute(1)
+
+	flaky := &limiterFlaky{}
+	flaky.down.Store(true)
+
+	var failures, open atomic.Int64
+	var logs bytes.Buffer
+	l, err := limiter.NewLimiterBreaker(flaky, limiter.LimiterBreakerOptions{
+		Mode:             limiter.FailOpen,
+		FailureThreshold: 2,
+		OpenTimeout:      50 * time.Millisecond,
+		OnError: func(err error) {
+			failures.Add(1)
+			if errors.Is(err, limiter.ErrBreakerOpen) {
+				open.Add(1)
+			}
+		},
+		Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	assert.Nil(t, err, "should be successful")
+
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, l.Allow(ctx, "foo", limit), "fails open")
+	}
+	assert.Equal(t, int64(2), flaky.calls.Load(), "the open breaker stops calling the limiter")
+	assert.Equal(t, int64(5), failures.Load(), "every degraded answer is reported")
+	assert.Equal(t, int64(3), open.Load())
+	assert.Equal(t, 1, strings.Count(logs.String(), "state changed"), "only the trip is logged")
+	assert.Contains(t, logs.String(), "from=closed to=open")
+	assert.Equal(t, limiter.BreakerStats{State: limiter.BreakerOpen, Failures: 2, Trips: 1, Degraded: 5}, l.Stats())
+
+	// a failed probe opens the breaker again
+	time.Sleep(60 * time.Millisecond)
+	assert.Nil(t, l.Allow(ctx, "foo", limit))
+	assert.Equal(t, int64(3), flaky.calls.Load())
+	assert.Equal(t, limiter.BreakerOpen, l.Stats().State)
+	assert.Equal(t, uint64(2), l.Stats().Trips)
+
+	flaky.down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	assert.Nil(t, l.Allow(ctx, "foo", limit))
+	assert.Equal(t, limiter.BreakerClosed, l.Stats().State, "a successful probe closes the breaker")
+
+	// open, half-open, open again, half-open and closed
+	assert.Equal(t, 5, strings.Count(logs.String(), "state changed"))
+	assert.Equal(t, 2, strings.Count(logs.String(), "from=open to=half-open"))
+	assert.Equal(t, 1, strings.Count(logs.String(), "from=half-open to=closed"))
+}
+
+func TestLimiterBreakerModes(t *testing.T) {
+	ctx := context.Background()
+	limit := redis_rate.PerMinute(1)
+
+	flaky := &limiterFlaky{}
+	flaky.down.Store(true)
+
+	l, err := limiter.NewLimiterBreaker(flaky, limiter.LimiterBreakerOptions{Mode: limiter.FailClosed, FailureThreshold: 1, OpenTimeout: time.Minute, Logger: discard})
+	assert.Nil(t, err, "should be successful")
+
+	assert.ErrorIs(t, l.Allow(ctx, "foo", limit), limiter.ErrRateLimited)
+	d, _ := limiter.RetryAfter(l.Allow(ctx, "foo", limit))
+	assert.InDelta(t, time.Minute, d, float64(time.Second), "retry once the breaker probes")
+	assert.Nil(t, l.Allow(limiter.Skip(ctx), "foo", limit), "skipped contexts are not limited")
+
+	_, err = limiter.NewLimiterBreaker(flaky, limiter.LimiterBreakerOptions{Mode: limiter.FailFallback})
+	assert.NotNil(t, err, "the fallback is required")
+
+	memory, err := limiter.NewLimiterMemory(ctx, limiter.LimiterMemoryOptions{})
+	assert.Nil(t, err, "should be successful")
+
+	l, err = limiter.NewLimiterBreaker(flaky, limiter.LimiterBreakerOptions{Mode: limiter.FailFallback, Fallback: memory, Logger: discard})
+	assert.Nil(t, err, "should be successful")
+
+	assert.Nil(t, l.Allow(ctx, "foo", limit))
+	assert.ErrorIs(t, l.Allow(ctx, "foo", limit), limiter.ErrRateLimited, "limited by the fallback")
+	assert.Equal(t, uint64(2), l.Stats().Degraded)
+}
+
+func TestLimiterBreakerProbe(t *testing.T) {
+	ctx := context.Background()
+	limit := redis_rate.PerMinute(1)
+	timeout := 100 * time.Millisecond
+
+	flaky := &limiterFlaky{}
+	l, err := limiter.NewLimiterBreaker(flaky, limiter.LimiterBreakerOptions{Mode: limiter.FailClosed, FailureThreshold: 1, OpenTimeout: timeout, Logger: discard})
+	assert.Nil(t, err, "should be successful")
+
+	// a request started before the breaker opened does not close it
+	done := make(chan error)
+	entered, release := flaky.hold()
+	go func() { done <- l.Allow(ctx, "foo", limit) }()
+	<-entered
+
+	flaky.down.Store(true)
+	assert.ErrorIs(t, l.Allow(ctx, "foo", limit), limiter.ErrRateLimited)
+	assert.Equal(t, limiter.BreakerOpen, l.Stats().State)
+
+	flaky.down.Store(false)
+	close(release)
+	assert.Nil(t, <-done)
+	assert.Equal(t, limiter.BreakerOpen, l.Stats().State)
+
+	// while the probe is in flight the others retry once it could have failed
+	time.Sleep(timeout)
+	entered, release = flaky.hold()
+	go func() { done <- l.Allow(ctx, "foo", limit) }()
+	<-entered
+	assert.Equal(t, limiter.BreakerHalfOpen, l.Stats().State)
+
+	d, ok := limiter.RetryAfter(l.Allow(ctx, "foo", limit))
+	assert.True(t, ok)
+	assert.Greater(t, d, time.Duration(0))
+	assert.LessOrEqual(t, d, timeout)
+
+	close(release)
+	assert.Nil(t, <-done)
+	assert.Equal(t, limiter.BreakerClosed, l.Stats().State, "the probe closes the breaker")
+}
@@ -0,0 +1,144 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrLeaseExpired = errors.New("lease expired")
+
+// Lease is a slot of a ConcurrencyRedis, release it once the work is done.
+type Lease struct {
+	Key string
+	ID  string
+	// skipped leases are not stored.
+	skipped bool
+}
+
+// acquireLease stores the leases of the key in a sorted set scored by their expiry in milliseconds.
+var acquireLease = redis.NewScript(`
+redis.replicate_commands()
+
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local ttl = tonumber(ARGV[2])
+local id = ARGV[3]
+
+local now = redis.call("TIME")
+now = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+
+redis.call("ZREMRANGEBYSCORE", key, "-inf", now)
+local used = redis.call("ZCARD", key)
+
+if used >= limit then
+  local first = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+  return {0, used, tonumber(first[2]) - now}
+end
+
+redis.call("ZADD", key, now + ttl, id)
+local last = redis.call("ZRANGE", key, -1, -1, "WITHSCORES")
+redis.call("PEXPIREAT", key, tonumber(last[2]))
+
+return {1, used + 1, 0}
+`)
+
+var extendLease = redis.NewScript(`
+redis.replicate_commands()
+
+local key = KEYS[1]
+local ttl = tonumber(ARGV[1])
+local id = ARGV[2]
+
+local now = redis.call("TIME")
+now = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+
+redis.call("ZREMRANGEBYSCORE", key, "-inf", now)
+if not redis.call("ZSCORE", key, id) then
+  return 0
+end
+
+redis.call("ZADD", key, "XX", now + ttl, id)
+local last = redis.call("ZRANGE", key, -1, -1, "WITHSCORES")
+redis.call("PEXPIREAT", key, tonumber(last[2]))
+
+return 1
+`)
+
+// ConcurrencyRedis bounds the in-flight work per key across instances, e.g. N jobs per tenant.
+// A lease expires after its ttl so crashed workers do not hold their slot forever, see Extend.
+type ConcurrencyRedis struct {
+	rdb redis.UniversalClient
+}
+
+func NewConcurrencyRedis(rdb redis.UniversalClient) (*ConcurrencyRedis, error) {
+	if rdb == nil {
+		return nil, errors.New("invalid redis client")
+	}
+
+	return &ConcurrencyRedis{rdb}, nil
+}
+
+// Acquire takes one of the limit slots of the key for ttl, the RateLimitedError retries after the first lease expiry.
+func (l *ConcurrencyRedis) Acquire(ctx context.Context, key string, limit int, ttl time.Duration) (*Lease, error) {
+	if limit <= 0 || ttl <= 0 {
+		return nil, ErrInvalidLimit
+	}
+
+	if Skipped(ctx) {
+		return &Lease{Key: key, skipped: true}, nil
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := acquireLease.Run(ctx, l.rdb, []string{"concurrency:" + key}, limit, ttl.Milliseconds(), id).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	if v[0] == 0 {
+		return nil, &RateLimitedError{RetryAfter: time.Duration(v[2]) * time.Millisecond}
+	}
+
+	return &Lease{Key: key, ID: id}, nil
+}
+
+// Extend renews the lease for ttl, long running work extends it periodically.
+// Returns ErrLeaseExpired when the slot was already given up.
+func (l *ConcurrencyRedis) Extend(ctx context.Context, lease *Lease, ttl time.Duration) error {
+	if lease.skipped {
+		return nil
+	}
+
+	ok, err := extendLease.Run(ctx, l.rdb, []string{"concurrency:" + lease.Key}, ttl.Milliseconds(), lease.ID).Int()
+	if err != nil {
+		return err
+	}
+
+	if ok == 0 {
+		return ErrLeaseExpired
+	}
+
+	return nil
+}
+
+func (l *ConcurrencyRedis) Release(ctx context.Context, lease *Lease) error {
+	if lease.skipped {
+		return nil
+	}
+
+	return l.rdb.ZRem(ctx, "concurrency:"+lease.Key, lease.ID).Err()
+}
+
+// InFlight returns the number of live leases of the key, by the clock of the caller.
+func (l *ConcurrencyRedis) InFlight(ctx context.Context, key string) (int, error) {
+	now := time.Now().UnixMilli()
+	n, err := l.rdb.ZCount(ctx, "concurrency:"+key, "("+strconv.FormatInt(now, 10), "+inf").Result()
+	return int(n), err
+}
@@ -3,6 +3,7 @@ package limiter
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/go-redis/redis_rate/v10"
 	"github.com/redis/go-redis/v9"
@@ -18,31 +19,131 @@ func Skip(ctx context.Context) context.Context {
 	return context.WithValue(ctx, ctxKeySkip, true)
 }
 
-var ErrRateLimited = errors.New("rate limited")
+// Skipped reports whether ctx was marked by Skip.
+func Skipped(ctx context.Context) bool {
+	v, ok := ctx.Value(ctxKeySkip).(bool)
+	return ok && v
+}
+
+var (
+	ErrRateLimited = errors.New("rate limited")
+	// ErrInvalidLimit is returned for a limit that is not positive, e.g. a zero Burst or Window, or a negative n.
+	ErrInvalidLimit = errors.New("invalid limit")
+)
+
+// ValidateLimit returns ErrInvalidLimit for the limits the limiters refuse.
+func ValidateLimit(limit redis_rate.Limit) error {
+	if limit.Rate <= 0 || limit.Period <= 0 || limit.Burst <= 0 {
+		return ErrInvalidLimit
+	}
+
+	return nil
+}
+
+func validateN(limit redis_rate.Limit, n int) error {
+	if n < 0 {
+		return ErrInvalidLimit
+	}
+
+	return ValidateLimit(limit)
+}
+
+// RateLimitedError is ErrRateLimited carrying when to retry, see errors.Is.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return ErrRateLimited.Error()
+}
+
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RetryAfter returns the delay carried by a RateLimitedError.
+func RetryAfter(err error) (time.Duration, bool) {
+	var target *RateLimitedError
+	if !errors.As(err, &target) {
+		return 0, false
+	}
+
+	return target.RetryAfter, true
+}
 
-type Limiter struct {
+// Limiter is a GCRA rate limiter: limit.Rate events per limit.Period with bursts of limit.Burst.
+// Skipped contexts are never limited.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit redis_rate.Limit) error
+	// AllowResult is Allow returning the state of the key, e.g. for the RateLimit-* headers.
+	// The result is set along a RateLimitedError, a skipped context gets a full budget.
+	AllowResult(ctx context.Context, key string, limit redis_rate.Limit) (*redis_rate.Result, error)
+	// AllowN consumes n events at once, e.g. a bulk endpoint costing one event per item.
+	// A zero n returns the state of the key without consuming it, a negative n is ErrInvalidLimit.
+	AllowN(ctx context.Context, key string, limit redis_rate.Limit, n int) (*redis_rate.Result, error)
+	// Reset clears the budget of the key, e.g. to unblock a client.
+	Reset(ctx context.Context, key string) error
+}
+
+// skipped is the result of a skipped context.
+func skipped(limit redis_rate.Limit, n int) *redis_rate.Result {
+	return &redis_rate.Result{Limit: limit, Allowed: n, Remaining: limit.Burst, RetryAfter: -1}
+}
+
+func result(res *redis_rate.Result, n int) (*redis_rate.Result, error) {
+	if n > 0 && res.Allowed <= 0 {
+		return res, &RateLimitedError{RetryAfter: res.RetryAfter, ResetAfter: res.ResetAfter}
+	}
+
+	return res, nil
+}
+
+// LimiterRedis shares the budgets between instances, see redis_rate.
+type LimiterRedis struct {
 	limiter *redis_rate.Limiter
 }
 
-func NewLimiter(rdb redis.UniversalClient) (*Limiter, error) {
+func NewLimiterRedis(rdb redis.UniversalClient) (*LimiterRedis, error) {
+	if rdb == nil {
+		return nil, errors.New("invalid redis client")
+	}
+
 	limiter := redis_rate.NewLimiter(rdb)
-	return &Limiter{limiter}, nil
+	return &LimiterRedis{limiter}, nil
 }
 
-func (l *Limiter) Allow(ctx context.Context, key string, limit redis_rate.Limit) error {
-	skip := ctx.Value(ctxKeySkip)
-	if v, ok := skip.(bool); ok && v {
-		return nil
+// NewLimiter is NewLimiterRedis.
+func NewLimiter(rdb redis.UniversalClient) (*LimiterRedis, error) {
+	return NewLimiterRedis(rdb)
+}
+
+func (l *LimiterRedis) Allow(ctx context.Context, key string, limit redis_rate.Limit) error {
+	_, err := l.AllowN(ctx, key, limit, 1)
+	return err
+}
+
+func (l *LimiterRedis) AllowResult(ctx context.Context, key string, limit redis_rate.Limit) (*redis_rate.Result, error) {
+	return l.AllowN(ctx, key, limit, 1)
+}
+
+func (l *LimiterRedis) AllowN(ctx context.Context, key string, limit redis_rate.Limit, n int) (*redis_rate.Result, error) {
+	if err := validateN(limit, n); err != nil {
+		return nil, err
 	}
 
-	res, err := l.limiter.Allow(ctx, key, limit)
-	if err != nil {
-		return err
+	if Skipped(ctx) {
+		return skipped(limit, n), nil
 	}
 
-	if res.Allowed <= 0 {
-		return ErrRateLimited
+	res, err := l.limiter.AllowN(ctx, key, limit, n)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return result(res, n)
+}
+
+func (l *LimiterRedis) Reset(ctx context.Context, key string) error {
+	return l.limiter.Reset(ctx, key)
 }
@@ -0,0 +1,188 @@
+package limiter_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis_rate/v10"
+	"github.com/hiendaovinh/toolkit/pkg/db"
+	"github.com/hiendaovinh/toolkit/pkg/limiter"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitedError(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &limiter.RateLimitedError{RetryAfter: time.Second})
+	assert.ErrorIs(t, err, limiter.ErrRateLimited)
+
+	d, ok := limiter.RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, d)
+
+	_, ok = limiter.RetryAfter(limiter.ErrRateLimited)
+	assert.False(t, ok)
+}
+
+// testLimiter is the conformance suite of the Limiter implementations.
+func testLimiter(t *testing.T, l limiter.Limiter) {
+	ctx := context.Background()
+	key := func(name string) string {
+		key := "test:" + t.Name() + ":" + name
+		t.Cleanup(func() { l.Reset(ctx, key) })
+		return key
+	}
+
+	t.Run("budget", func(t *testing.T) {
+		k := key("budget")
+		limit := redis_rate.PerMinute(10)
+
+		res, err := l.AllowN(ctx, k, limit, 8)
+		assert.Nil(t, err, "should be successful")
+		assert.Equal(t, 8, res.Allowed)
+		assert.Equal(t, 2, res.Remaining)
+		assert.Equal(t, time.Duration(-1), res.RetryAfter)
+		assert.InDelta(t, 48*time.Second, res.ResetAfter, float64(time.Second))
+
+		res, err = l.AllowN(ctx, k, limit, 3)
+		assert.ErrorIs(t, err, limiter.ErrRateLimited)
+		assert.Equal(t, 0, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+		assert.InDelta(t, 6*time.Second, res.RetryAfter, float64(time.Second))
+
+		d, ok := limiter.RetryAfter(err)
+		assert.True(t, ok)
+		assert.Equal(t, res.RetryAfter, d)
+
+		res, err = l.AllowResult(ctx, k, limit)
+		assert.Nil(t, err, "should be successful")
+		assert.Equal(t, 1, res.Remaining)
+	})
+
+	t.Run("burst", func(t *testing.T) {
+		k := key("burst")
+		limit := redis_rate.Limit{Rate: 1, Period: time.Second, Burst: 3}
+
+		for i := 0; i < 3; i++ {
+			assert.Nil(t, l.Allow(ctx, k, limit), "should be successful")
+		}
+
+		res, err := l.AllowResult(ctx, k, limit)
+		assert.ErrorIs(t, err, limiter.ErrRateLimited)
+		assert.Greater(t, res.RetryAfter, time.Duration(0))
+		assert.LessOrEqual(t, res.RetryAfter, time.Second)
+
+		assert.Nil(t, l.Allow(ctx, key("other"), limit), "keys are independent")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		k := key("invalid")
+		for _, limit := range []redis_rate.Limit{
+			{Rate: 0, Period: time.Second, Burst: 1},
+			{Rate: 1, Period: 0, Burst: 1},
+			{Rate: 1, Period: time.Second, Burst: 0},
+		} {
+			_, err := l.AllowN(ctx, k, limit, 1)
+			assert.ErrorIs(t, err, limiter.ErrInvalidLimit)
+		}
+
+		_, err := l.AllowN(ctx, k, redis_rate.PerMinute(10), -1)
+		assert.ErrorIs(t, err, limiter.ErrInvalidLimit)
+	})
+
+	t.Run("peek", func(t *testing.T) {
+		k := key("peek")
+		limit := redis_rate.PerMinute(10)
+
+		res, err := l.AllowN(ctx, k, limit, 0)
+		assert.Nil(t, err, "should be successful")
+		assert.Equal(t, 0, res.Allowed)
+		assert.Equal(t, 10, res.Remaining)
+
+		_, err = l.AllowN(ctx, k, limit, 4)
+		assert.Nil(t, err, "should be successful")
+
+		for range 2 {
+			res, err = l.AllowN(ctx, k, limit, 0)
+			assert.Nil(t, err, "should be successful")
+			assert.Equal(t, 6, res.Remaining, "nothing is consumed")
+		}
+	})
+
+	t.Run("recovery", func(t *testing.T) {
+		k := key("recovery")
+		limit := redis_rate.Limit{Rate: 1, Period: 100 * time.Millisecond, Burst: 1}
+
+		assert.Nil(t, l.Allow(ctx, k, limit), "should be successful")
+		res, err := l.AllowResult(ctx, k, limit)
+		assert.ErrorIs(t, err, limiter.ErrRateLimited)
+
+		time.Sleep(res.RetryAfter + 10*time.Millisecond)
+		assert.Nil(t, l.Allow(ctx, k, limit), "should be successful")
+	})
+
+	t.Run("skip and reset", func(t *testing.T) {
+		k := key("reset")
+		limit := redis_rate.PerHour(1)
+
+		assert.Nil(t, l.Allow(ctx, k, limit), "should be successful")
+		assert.ErrorIs(t, l.Allow(ctx, k, limit), limiter.ErrRateLimited)
+
+		res, err := l.AllowN(limiter.Skip(ctx), k, limit, 5)
+		assert.Nil(t, err, "skipped contexts are not limited")
+		assert.Equal(t, 5, res.Allowed)
+
+		assert.Nil(t, l.Reset(ctx, k))
+		assert.Nil(t, l.Allow(ctx, k, limit), "should be successful")
+	})
+}
+
+func TestLimiterMemory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	l, err := limiter.NewLimiterMemory(ctx, limiter.LimiterMemoryOptions{})
+	assert.Nil(t, err, "should be successful")
+
+	testLimiter(t, l)
+}
+
+func TestLimiterMemoryEviction(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	l, err := limiter.NewLimiterMemory(ctx, limiter.LimiterMemoryOptions{MaxKeys: 2, CleanupInterval: 10 * time.Millisecond})
+	assert.Nil(t, err, "should be successful")
+
+	limit := redis_rate.Limit{Rate: 1, Period: 50 * time.Millisecond, Burst: 1}
+	for _, key := range []string{"a", "b", "c"} {
+		assert.Nil(t, l.Allow(context.Background(), key, limit), "should be successful")
+	}
+	assert.Equal(t, 2, l.Len())
+	assert.ErrorIs(t, l.Allow(context.Background(), "c", limit), limiter.ErrRateLimited, "the newest key is kept")
+	assert.ErrorIs(t, l.Allow(context.Background(), "b", limit), limiter.ErrRateLimited, "only the key closest to idle is evicted")
+
+	assert.Eventually(t, func() bool { return l.Len() == 0 }, time.Second, 10*time.Millisecond, "idle keys are evicted")
+}
+
+func testRedis(t *testing.T) *redis.Client {
+	url := os.Getenv("TOOLKIT_TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TOOLKIT_TEST_REDIS_URL is not set")
+	}
+
+	client, err := db.InitRedis(&db.RedisConfig{URL: url})
+	assert.Nil(t, err, "should be successful")
+
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestLimiterRedis(t *testing.T) {
+	l, err := limiter.NewLimiterRedis(testRedis(t))
+	assert.Nil(t, err, "should be successful")
+
+	testLimiter(t, l)
+}
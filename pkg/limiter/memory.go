@@ -0,0 +1,193 @@
+package limiter
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis_rate/v10"
+)
+
+type LimiterMemoryOptions struct {
+	// MaxKeys bounds the tracked keys, the closest to idle is evicted when full. Zero is unbounded.
+	MaxKeys int
+	// CleanupInterval is the period of the eviction of idle keys, defaults to a minute.
+	CleanupInterval time.Duration
+}
+
+// LimiterMemory is the GCRA of LimiterRedis for a single instance, e.g. tests and CLI tools.
+// A key is idle once its budget is full again, it is then forgotten like an expired Redis key.
+type LimiterMemory struct {
+	opts LimiterMemoryOptions
+
+	mu   sync.Mutex
+	keys map[string]*tatEntry
+	// tats orders the keys by theoretical arrival time, the closest to idle first.
+	tats tatHeap
+}
+
+type tatEntry struct {
+	key   string
+	tat   time.Time
+	index int
+}
+
+// tatHeap is a container/heap of the keys, ordered by TAT.
+type tatHeap []*tatEntry
+
+func (h tatHeap) Len() int           { return len(h) }
+func (h tatHeap) Less(i, j int) bool { return h[i].tat.Before(h[j].tat) }
+
+func (h tatHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *tatHeap) Push(x any) {
+	entry := x.(*tatEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *tatHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// NewLimiterMemory evicts idle keys until ctx is done.
+func NewLimiterMemory(ctx context.Context, opts LimiterMemoryOptions) (*LimiterMemory, error) {
+	if opts.CleanupInterval <= 0 {
+		opts.CleanupInterval = time.Minute
+	}
+
+	l := &LimiterMemory{opts: opts, keys: map[string]*tatEntry{}}
+	go l.cleanup(ctx)
+
+	return l, nil
+}
+
+func (l *LimiterMemory) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(l.opts.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			l.evict(time.Now())
+			l.mu.Unlock()
+		}
+	}
+}
+
+// evict drops the idle keys.
+func (l *LimiterMemory) evict(now time.Time) {
+	for len(l.tats) > 0 && !l.tats[0].tat.After(now) {
+		l.pop()
+	}
+}
+
+// pop drops the key closest to idle.
+func (l *LimiterMemory) pop() {
+	entry := heap.Pop(&l.tats).(*tatEntry)
+	delete(l.keys, entry.key)
+}
+
+// Len returns the number of tracked keys.
+func (l *LimiterMemory) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.keys)
+}
+
+func (l *LimiterMemory) Allow(ctx context.Context, key string, limit redis_rate.Limit) error {
+	_, err := l.AllowN(ctx, key, limit, 1)
+	return err
+}
+
+func (l *LimiterMemory) AllowResult(ctx context.Context, key string, limit redis_rate.Limit) (*redis_rate.Result, error) {
+	return l.AllowN(ctx, key, limit, 1)
+}
+
+// AllowN follows the allowN script of redis_rate.
+func (l *LimiterMemory) AllowN(ctx context.Context, key string, limit redis_rate.Limit, n int) (*redis_rate.Result, error) {
+	if err := validateN(limit, n); err != nil {
+		return nil, err
+	}
+
+	if Skipped(ctx) {
+		return skipped(limit, n), nil
+	}
+
+	emissionInterval := limit.Period / time.Duration(limit.Rate)
+	increment := emissionInterval * time.Duration(n)
+	burstOffset := emissionInterval * time.Duration(limit.Burst)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	tat := now
+	entry, ok := l.keys[key]
+	if ok && entry.tat.After(now) {
+		tat = entry.tat
+	}
+
+	newTAT := tat.Add(increment)
+	diff := now.Sub(newTAT.Add(-burstOffset))
+	if diff < 0 {
+		return result(&redis_rate.Result{
+			Limit:      limit,
+			RetryAfter: -diff,
+			ResetAfter: tat.Sub(now),
+		}, n)
+	}
+
+	resetAfter := newTAT.Sub(now)
+	if resetAfter > 0 {
+		switch {
+		case ok:
+			entry.tat = newTAT
+			heap.Fix(&l.tats, entry.index)
+		default:
+			if l.opts.MaxKeys > 0 && len(l.keys) >= l.opts.MaxKeys {
+				l.evict(now)
+				if len(l.keys) >= l.opts.MaxKeys {
+					l.pop()
+				}
+			}
+
+			entry = &tatEntry{key: key, tat: newTAT}
+			heap.Push(&l.tats, entry)
+			l.keys[key] = entry
+		}
+	}
+
+	return result(&redis_rate.Result{
+		Limit:      limit,
+		Allowed:    n,
+		Remaining:  int(diff / emissionInterval),
+		RetryAfter: -1,
+		ResetAfter: resetAfter,
+	}, n)
+}
+
+func (l *LimiterMemory) Reset(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if entry, ok := l.keys[key]; ok {
+		heap.Remove(&l.tats, entry.index)
+		delete(l.keys, key)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type QuotaPeriod uint8
+
+const (
+	QuotaDaily QuotaPeriod = iota
+	// QuotaWeekly weeks start on Monday.
+	QuotaWeekly
+	QuotaMonthly
+	QuotaYearly
+)
+
+func (p QuotaPeriod) String() string {
+	switch p {
+	case QuotaWeekly:
+		return "weekly"
+	case QuotaMonthly:
+		return "monthly"
+	case QuotaYearly:
+		return "yearly"
+	}
+
+	return "daily"
+}
+
+// Quota allows Limit events per calendar period, e.g. monthly API calls.
+type Quota struct {
+	Limit  int
+	Period QuotaPeriod
+	// Location of the calendar, defaults to UTC.
+	Location *time.Location
+}
+
+// bounds returns the period containing t.
+func (q Quota) bounds(t time.Time) (time.Time, time.Time) {
+	loc := q.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	t = t.In(loc)
+	y, m, d := t.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, loc)
+
+	switch q.Period {
+	case QuotaWeekly:
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7)
+	case QuotaMonthly:
+		start = time.Date(y, m, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0)
+	case QuotaYearly:
+		start = time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(1, 0, 0)
+	}
+
+	return start, start.AddDate(0, 0, 1)
+}
+
+var consumeQuota = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local cost = tonumber(ARGV[2])
+local expire_at = tonumber(ARGV[3])
+
+local used = tonumber(redis.call("GET", key) or "0")
+if used + cost > limit then
+  return {0, used}
+end
+
+used = redis.call("INCRBY", key, cost)
+redis.call("EXPIREAT", key, expire_at)
+
+return {cost, used}
+`)
+
+// QuotaRedis counts the events of each period under its own key, e.g. quota:tenant:daily:2024-05-01,
+// so quotas of different periods on the same key are independent.
+// Counters are kept one more period after theirs, see UsageAt.
+type QuotaRedis struct {
+	rdb redis.UniversalClient
+}
+
+func NewQuotaRedis(rdb redis.UniversalClient) (*QuotaRedis, error) {
+	if rdb == nil {
+		return nil, errors.New("invalid redis client")
+	}
+
+	return &QuotaRedis{rdb}, nil
+}
+
+func quotaKey(key string, quota Quota, start time.Time) string {
+	return "quota:" + key + ":" + quota.Period.String() + ":" + start.Format(time.DateOnly)
+}
+
+func (l *QuotaRedis) Allow(ctx context.Context, key string, quota Quota) (*Usage, error) {
+	return l.AllowN(ctx, key, quota, 1)
+}
+
+// AllowN counts n events at once, none when they exceed the quota. The RateLimitedError retries at the next period.
+func (l *QuotaRedis) AllowN(ctx context.Context, key string, quota Quota, n int) (*Usage, error) {
+	if quota.Limit <= 0 || n < 0 {
+		return nil, ErrInvalidLimit
+	}
+
+	now := time.Now()
+	start, end := quota.bounds(now)
+	if Skipped(ctx) {
+		return &Usage{Limit: quota.Limit, Remaining: quota.Limit, ResetAfter: end.Sub(now)}, nil
+	}
+
+	expireAt := end.Add(end.Sub(start)).Unix()
+	v, err := consumeQuota.Run(ctx, l.rdb, []string{quotaKey(key, quota, start)}, quota.Limit, n, expireAt).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{
+		Limit:      quota.Limit,
+		Used:       int(v[1]),
+		Remaining:  max(quota.Limit-int(v[1]), 0),
+		ResetAfter: end.Sub(now),
+	}
+
+	if v[0] == 0 {
+		return usage, &RateLimitedError{RetryAfter: usage.ResetAfter, ResetAfter: usage.ResetAfter}
+	}
+
+	return usage, nil
+}
+
+// UsageAt returns the usage of the period containing t, e.g. last month for billing.
+func (l *QuotaRedis) UsageAt(ctx context.Context, key string, quota Quota, t time.Time) (*Usage, error) {
+	start, end := quota.bounds(t)
+	used, err := l.rdb.Get(ctx, quotaKey(key, quota, start)).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	return &Usage{
+		Limit:      quota.Limit,
+		Used:       used,
+		Remaining:  max(quota.Limit-used, 0),
+		ResetAfter: max(time.Until(end), 0),
+	}, nil
+}
+
+// Reset clears the counter of the current period.
+func (l *QuotaRedis) Reset(ctx context.Context, key string, quota Quota) error {
+	start, _ := quota.bounds(time.Now())
+	return l.rdb.Del(ctx, quotaKey(key, quota, start)).Err()
+}
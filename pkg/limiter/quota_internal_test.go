@@ -0,0 +1,70 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaBounds(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err, "should be successful")
+
+	cases := []struct {
+		name       string
+		quota      Quota
+		t          time.Time
+		start, end time.Time
+	}{
+		{
+			name:  "weekly on a monday",
+			quota: Quota{Period: QuotaWeekly},
+			t:     time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+			start: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "weekly on a sunday",
+			quota: Quota{Period: QuotaWeekly},
+			t:     time.Date(2024, 5, 12, 23, 59, 0, 0, time.UTC),
+			start: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "monthly",
+			quota: Quota{Period: QuotaMonthly},
+			t:     time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+			start: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "daily across DST",
+			quota: Quota{Period: QuotaDaily, Location: newYork},
+			t:     time.Date(2024, 3, 10, 12, 0, 0, 0, newYork),
+			start: time.Date(2024, 3, 10, 0, 0, 0, 0, newYork),
+			end:   time.Date(2024, 3, 11, 0, 0, 0, 0, newYork),
+		},
+		{
+			// sunday night in New York, already monday in UTC
+			name:  "weekly across DST",
+			quota: Quota{Period: QuotaWeekly, Location: newYork},
+			t:     time.Date(2024, 11, 4, 4, 30, 0, 0, time.UTC),
+			start: time.Date(2024, 10, 28, 0, 0, 0, 0, newYork),
+			end:   time.Date(2024, 11, 4, 0, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, c := range cases {
+		start, end := c.quota.bounds(c.t)
+		assert.True(t, c.start.Equal(start), "%s: start %s", c.name, start)
+		assert.True(t, c.end.Equal(end), "%s: end %s", c.name, end)
+	}
+
+	// periods follow the calendar, not a fixed duration
+	start, end := Quota{Period: QuotaDaily, Location: newYork}.bounds(time.Date(2024, 3, 10, 12, 0, 0, 0, newYork))
+	assert.Equal(t, 23*time.Hour, end.Sub(start))
+
+	start, end = Quota{Period: QuotaWeekly, Location: newYork}.bounds(time.Date(2024, 11, 4, 4, 30, 0, 0, time.UTC))
+	assert.Equal(t, 7*24*time.Hour+time.Hour, end.Sub(start))
+}
@@ -0,0 +1,132 @@
+package limiter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Usage is the state of a sliding window or a quota.
+type Usage struct {
+	Limit     int
+	Used      int
+	Remaining int
+	// ResetAfter is the time until the budget is full again.
+	ResetAfter time.Duration
+}
+
+// Window allows Limit events in any Window, unlike GCRA there is no bursting above Limit.
+type Window struct {
+	Limit  int
+	Window time.Duration
+}
+
+// slidingWindow logs the events of the key in a sorted set scored by their time in microseconds.
+var slidingWindow = redis.NewScript(`
+redis.replicate_commands()
+
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local id = ARGV[4]
+
+local now = redis.call("TIME")
+now = tonumber(now[1]) * 1000000 + tonumber(now[2])
+
+redis.call("ZREMRANGEBYSCORE", key, "-inf", now - window)
+local used = redis.call("ZCARD", key)
+
+if used + cost > limit then
+  local retry_after = -1
+  local reset_after = 0
+  if used > 0 then
+    local newest = redis.call("ZRANGE", key, -1, -1, "WITHSCORES")
+    reset_after = tonumber(newest[2]) + window - now
+  end
+
+  if cost <= limit then
+    local i = used + cost - limit - 1
+    local oldest = redis.call("ZRANGE", key, i, i, "WITHSCORES")
+    retry_after = tonumber(oldest[2]) + window - now
+  end
+
+  return {0, used, retry_after, reset_after}
+end
+
+for i = 1, cost do
+  redis.call("ZADD", key, now, id .. ":" .. i)
+end
+redis.call("PEXPIRE", key, math.ceil(window / 1000))
+
+return {cost, used + cost, -1, window}
+`)
+
+// SlidingWindowRedis is a sliding window log, e.g. strict "N per hour" business rules.
+// Each event is stored until it leaves the window, prefer Limiter for large limits.
+type SlidingWindowRedis struct {
+	rdb redis.UniversalClient
+}
+
+func NewSlidingWindowRedis(rdb redis.UniversalClient) (*SlidingWindowRedis, error) {
+	if rdb == nil {
+		return nil, errors.New("invalid redis client")
+	}
+
+	return &SlidingWindowRedis{rdb}, nil
+}
+
+func (l *SlidingWindowRedis) Allow(ctx context.Context, key string, limit Window) (*Usage, error) {
+	return l.AllowN(ctx, key, limit, 1)
+}
+
+// AllowN logs n events at once, none when they do not fit in the window.
+func (l *SlidingWindowRedis) AllowN(ctx context.Context, key string, limit Window, n int) (*Usage, error) {
+	if limit.Limit <= 0 || limit.Window <= 0 || n < 0 {
+		return nil, ErrInvalidLimit
+	}
+
+	if Skipped(ctx) {
+		return &Usage{Limit: limit.Limit, Remaining: limit.Limit}, nil
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := slidingWindow.Run(ctx, l.rdb, []string{"sliding:" + key}, limit.Limit, limit.Window.Microseconds(), n, id).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{
+		Limit:      limit.Limit,
+		Used:       int(v[1]),
+		Remaining:  max(limit.Limit-int(v[1]), 0),
+		ResetAfter: time.Duration(v[3]) * time.Microsecond,
+	}
+
+	if v[0] == 0 {
+		return usage, &RateLimitedError{RetryAfter: time.Duration(v[2]) * time.Microsecond, ResetAfter: usage.ResetAfter}
+	}
+
+	return usage, nil
+}
+
+func (l *SlidingWindowRedis) Reset(ctx context.Context, key string) error {
+	return l.rdb.Del(ctx, "sliding:"+key).Err()
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}